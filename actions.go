@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// printError writes an error for one feed to w. Inside GitHub Actions it
+// becomes a workflow annotation so it stands out in the run's log, and
+// with --format json it is a JSON error object.
+func printError(w io.Writer, err error, opts options) {
+	if opts.format == "json" {
+		writeJSONError(w, err)
+		return
+	}
+	if opts.githubActions {
+		fmt.Fprintf(w, "::notice::%s\n", escapeWorkflowData("Error: "+err.Error()))
+		return
+	}
+	fmt.Fprintf(w, "Error: %v\n", err)
+}
+
+// escapeWorkflowData encodes s for use in a workflow command, where a raw
+// newline would end the command early.
+func escapeWorkflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
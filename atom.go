@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// atomFeed is the root element of an Atom (RFC 4287) feed.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomAuthor is required by the spec on the feed when entries don't
+// carry their own.
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// atomEntry is a single event in the feed.
+type atomEntry struct {
+	ID      string    `xml:"id"`
+	Title   string    `xml:"title"`
+	Updated string    `xml:"updated"`
+	Link    *atomLink `xml:"link,omitempty"` // nil when there's no page to link
+}
+
+// atomLink points at the page an entry is about.
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+// writeAtom renders events as an Atom feed so they can be followed in a
+// feed reader. encoding/xml takes care of escaping titles. Several
+// sections become one feed, newest first, with each entry naming who did
+// it.
+func writeAtom(w io.Writer, sections []section, opts options) error {
+	logins, events, several := combineSections(sections)
+	profile := webURL(opts) + "/" + strings.Join(logins, ",")
+	id := profile
+	if several {
+		// There is no page showing several users' activity, so the feed
+		// links the site and gets a tag URI as its ID.
+		profile = webURL(opts)
+		id = tagURI(opts, "activity/"+strings.Join(logins, ","))
+	}
+	feed := atomFeed{
+		ID:      id,
+		Title:   "GitHub activity for " + strings.Join(logins, ", "),
+		Updated: now().UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: profile},
+		Author:  atomAuthor{Name: strings.Join(logins, ", ")},
+	}
+	// Events arrive newest first, so the first one dates the feed.
+	if len(events) > 0 && !events[0].CreatedAt.IsZero() {
+		feed.Updated = events[0].CreatedAt.UTC().Format(time.RFC3339)
+	}
+
+	for _, event := range events {
+		title := formatEvent(event, opts)
+		if several && event.Actor.Login != "" {
+			title = event.Actor.Login + " " + lowerFirst(title)
+		}
+		entry := atomEntry{
+			ID:      tagURI(opts, "event/"+event.ID),
+			Title:   title,
+			Updated: event.CreatedAt.UTC().Format(time.RFC3339),
+		}
+		if href := eventURL(event, opts); href != "" {
+			entry.Link = &atomLink{Href: href}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// tagURI is a tag URI (RFC 4151) for an Atom ID, such as
+// "tag:github.com,2008:event/123", minted under the web host the events
+// come from, so that a GitHub Enterprise server's IDs can't collide with
+// github.com's.
+func tagURI(opts options, specific string) string {
+	host := "github.com"
+	if u, err := url.Parse(webURL(opts)); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,2008:%s", host, specific)
+}
+
+// eventURL is the most specific web page for an event: the issue, pull
+// request or release it concerns if there is one, otherwise its repository.
+// It is "" for an event on a deleted repository, which has no page.
+func eventURL(event Event, opts options) string {
+	switch {
+	case event.Payload.PullRequest.HTMLURL != "":
+		return event.Payload.PullRequest.HTMLURL
+	case event.Payload.Issue.HTMLURL != "":
+		return event.Payload.Issue.HTMLURL
+	case event.Payload.Release.HTMLURL != "":
+		return event.Payload.Release.HTMLURL
+	}
+	if event.Repo.Name == "" {
+		return ""
+	}
+	return repoURL(event.Repo.Name, opts)
+}
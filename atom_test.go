@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+func TestWriteAtomIsWellFormed(t *testing.T) {
+	events := []Event{
+		{Event: activity.Event{
+			ID:        "2",
+			Type:      "PullRequestEvent",
+			Repo:      activity.Repo{Name: "octocat/hello"},
+			CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Payload: activity.Payload{
+				Action:      "opened",
+				PullRequest: activity.Issue{Title: "Fix <br> & ]]> in titles", HTMLURL: "https://github.com/octocat/hello/pull/7"},
+			},
+		}},
+		{Event: activity.Event{
+			ID:        "1",
+			Type:      "WatchEvent",
+			Repo:      activity.Repo{Name: "octocat/hello"},
+			CreatedAt: time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC),
+			Payload:   activity.Payload{Action: "started"},
+		}},
+	}
+	var b strings.Builder
+	if err := writeAtom(&b, []section{{feed: feed{login: "octocat"}, events: events}}, options{baseURL: activity.DefaultBaseURL}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Walking every token fails on anything that isn't well-formed.
+	dec := xml.NewDecoder(strings.NewReader(b.String()))
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("output isn't well-formed XML: %v\n%s", err, b.String())
+		}
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal([]byte(b.String()), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if feed.ID != "https://github.com/octocat" || feed.Updated != "2024-05-01T12:00:00Z" {
+		t.Errorf("feed id, updated = %q, %q; want the profile and the newest event's time", feed.ID, feed.Updated)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(feed.Entries))
+	}
+	first := feed.Entries[0]
+	if want := `Opened a pull request in octocat/hello: "Fix <br> & ]]> in titles"`; first.Title != want {
+		t.Errorf("title = %q, want %q", first.Title, want)
+	}
+	if first.ID != "tag:github.com,2008:event/2" {
+		t.Errorf("entry id = %q", first.ID)
+	}
+	if first.Link == nil || first.Link.Href != "https://github.com/octocat/hello/pull/7" {
+		t.Errorf("entry link = %+v, want the pull request", first.Link)
+	}
+}
+
+func TestAtomForEnterprise(t *testing.T) {
+	events := []Event{
+		{Event: activity.Event{ID: "9", Type: "WatchEvent", Repo: activity.Repo{Name: "o/r"}}},
+		{Event: activity.Event{ID: "8", Type: "WatchEvent"}}, // a deleted repository
+	}
+	opts := options{baseURL: "https://github.example.com/api/v3"}
+	var b strings.Builder
+	if err := writeAtom(&b, []section{{feed: feed{login: "mona"}, events: events}}, opts); err != nil {
+		t.Fatal(err)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal([]byte(b.String()), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if feed.Link.Href != "https://github.example.com/mona" {
+		t.Errorf("feed link = %q, want the profile on the web host", feed.Link.Href)
+	}
+	if got := feed.Entries[0]; got.ID != "tag:github.example.com,2008:event/9" || got.Link == nil || got.Link.Href != "https://github.example.com/o/r" {
+		t.Errorf("entry = %+v, want a tag and a link on github.example.com", got)
+	}
+	if got := feed.Entries[1]; got.Link != nil {
+		t.Errorf("entry on a deleted repository links %q", got.Link.Href)
+	}
+	if strings.Contains(b.String(), "api/v3") {
+		t.Errorf("the feed links the API instead of the web host:\n%s", b.String())
+	}
+}
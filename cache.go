@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cacheMu serializes access to the cache files, which concurrent fetches
+// for several users may read and rewrite at the same time.
+var cacheMu sync.Mutex
+
+// cacheOverride is the directory given by --cache-dir or
+// GITHUB_ACTIVITY_CACHE_DIR, used instead of the default when set.
+var cacheOverride string
+
+// cacheDisabled turns readCache and writeCache into no-ops. It is set when
+// checkCacheDir finds the directory unusable, so that a read-only home
+// loses the cache rather than failing the run.
+var cacheDisabled bool
+
+// cacheDir is where the tool keeps state between runs.
+func cacheDir() (string, error) {
+	if cacheOverride != "" {
+		return cacheOverride, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "github-activity"), nil
+}
+
+// readCache decodes the cache file name into v. A missing file leaves v
+// untouched and isn't an error. The caller must hold cacheMu.
+func readCache(name string, v any) error {
+	if cacheDisabled {
+		return nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeCache replaces the cache file name with v encoded as JSON. The
+// caller must hold cacheMu.
+func writeCache(name string, v any) error {
+	if cacheDisabled {
+		return nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	if err := makeCacheDir(dir); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so a crash can't leave half a file.
+	// CreateTemp makes it readable by the owner only, like the directory.
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// makeCacheDir creates the cache directory, readable by the owner only:
+// the cached pages can include private events seen with a token.
+// Directories left by older versions, which were world-readable, are
+// tightened too.
+func makeCacheDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	return os.Chmod(dir, 0o700)
+}
+
+// checkCacheDir makes sure the cache directory exists and can be written
+// to, by creating and removing a file in it.
+func checkCacheDir() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	if err := makeCacheDir(dir); err != nil {
+		return dir, err
+	}
+	f, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return dir, err
+	}
+	f.Close()
+	return dir, os.Remove(f.Name())
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// eventSymbols gives each event type with dedicated formatting the single
+// character --compact draws for it. Other types are drawn as ".".
+var eventSymbols = map[string]string{
+	"PushEvent":         "P",
+	"PullRequestEvent":  "R",
+	"IssuesEvent":       "I",
+	"IssueCommentEvent": "C",
+	"CreateEvent":       "N",
+	"DeleteEvent":       "D",
+	"WatchEvent":        "W",
+	"ForkEvent":         "F",
+	"ReleaseEvent":      "V",
+	"PublicEvent":       "O",
+
+	"PullRequestReviewEvent":        "R",
+	"PullRequestReviewCommentEvent": "C",
+	"CommitCommentEvent":            "C",
+	"MemberEvent":                   "M",
+	"GollumEvent":                   "G",
+	"SponsorshipEvent":              "S",
+}
+
+// printCompact writes one line per day with a symbol per event, oldest day
+// and oldest event first, so that the shape of the activity shows at a
+// glance:
+//
+//	2024-05-01  PPIPW
+//	2024-05-02  FPPP
+func printCompact(w io.Writer, events []Event, opts options) {
+	groups := groupEvents(events, "day", location(opts))
+	// Events arrive newest first; reading left to right should go forward
+	// in time.
+	for i := len(groups) - 1; i >= 0; i-- {
+		var b strings.Builder
+		day := groups[i].Events
+		for j := len(day) - 1; j >= 0; j-- {
+			symbol, ok := eventSymbols[day[j].Type]
+			if !ok {
+				symbol = "."
+			}
+			b.WriteString(symbol)
+		}
+		fmt.Fprintf(w, "%s  %s\n", groups[i].Key, b.String())
+	}
+}
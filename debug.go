@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redactor replaces repository names with placeholders in --debug and
+// --raw dumps when --redact-repos is given, so that the dumps can be
+// attached to a bug report without revealing private repositories. The
+// same name always gets the same placeholder within a run. A nil
+// *redactor leaves everything as it is.
+type redactor struct {
+	mu    sync.Mutex
+	names map[string]string // real name -> placeholder
+}
+
+// newRedactor returns a redactor, or nil when disabled.
+func newRedactor(enabled bool) *redactor {
+	if !enabled {
+		return nil
+	}
+	return &redactor{names: make(map[string]string)}
+}
+
+// addEvents learns the repository names of events.
+func (r *redactor) addEvents(events []Event) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range events {
+		if name := event.Repo.Name; name != "" && r.names[name] == "" {
+			r.names[name] = fmt.Sprintf("redacted/repo-%d", len(r.names)+1)
+		}
+	}
+}
+
+// redact replaces every known repository name in s.
+func (r *redactor) redact(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.names))
+	for name := range r.names {
+		names = append(names, name)
+	}
+	// Longest first, so "o/repo-two" isn't half-replaced by "o/repo".
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, name, r.names[name])
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// debugTransport logs every request and response to w for --debug, with
+// the token and, with --redact-repos, repository names redacted.
+type debugTransport struct {
+	base   http.RoundTripper
+	w      io.Writer
+	redact *redactor
+}
+
+func (t debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var b bytes.Buffer
+	printRequest(&b, req)
+	t.log("> ", b.String())
+
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.log("< ", fmt.Sprintf("error after %v: %v\n", time.Since(started).Round(time.Millisecond), err))
+		return nil, err
+	}
+
+	b.Reset()
+	fmt.Fprintf(&b, "%s (%v)\n", resp.Status, time.Since(started).Round(time.Millisecond))
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			fmt.Fprintf(&b, "%s: %s\n", name, redactHeader(name, value))
+		}
+	}
+	t.log("< ", b.String())
+	return resp, nil
+}
+
+// log writes each line of s to w behind prefix, in a single write so that
+// concurrent requests don't interleave mid-block.
+func (t debugTransport) log(prefix, s string) {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(t.redact.redact(s), "\n") {
+		if line != "" {
+			b.WriteString(prefix + line)
+		}
+	}
+	fmt.Fprint(t.w, b.String())
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// rateLimit is the core resource of the /rate_limit response.
+type rateLimit struct {
+	Resources struct {
+		Core struct {
+			Limit     int   `json:"limit"`
+			Remaining int   `json:"remaining"`
+			Reset     int64 `json:"reset"`
+		} `json:"core"`
+	} `json:"resources"`
+}
+
+// runDoctor checks that the configured API can be reached and that the
+// token, if any, is accepted, printing PASS, WARN, FAIL or SKIP for each
+// check. It uses the same requests as a real run, so what passes here
+// works there. /rate_limit doesn't count against the rate limit.
+func runDoctor(ctx context.Context, w io.Writer, opts options) int {
+	report := func(status, check, detail string) {
+		fmt.Fprintf(w, "%-4s  %-12s %s\n", status, check, detail)
+	}
+
+	report("INFO", "base URL", opts.baseURL)
+
+	apiURL := strings.TrimSuffix(opts.baseURL, "/") + "/rate_limit"
+	req, err := newRequest(ctx, apiURL, opts)
+	if err != nil {
+		report("FAIL", "connectivity", err.Error())
+		return exitUsage
+	}
+	started := time.Now()
+	resp, err := newHTTPClient(opts).Do(req)
+	if err != nil {
+		report("FAIL", "connectivity", err.Error())
+		return exitNetwork
+	}
+	defer resp.Body.Close()
+	report("PASS", "connectivity", fmt.Sprintf("%s answered in %v", req.URL.Host, time.Since(started).Round(time.Millisecond)))
+
+	switch {
+	case opts.token == "":
+		report("SKIP", "token", "no token is set (--token, GITHUB_TOKEN or ~/.netrc); requests are anonymous")
+	case resp.StatusCode == http.StatusUnauthorized:
+		report("FAIL", "token", fmt.Sprintf("the token from %s was rejected (401); it may be expired or revoked", opts.tokenFrom))
+		return exitFailure
+	default:
+		report("PASS", "token", fmt.Sprintf("the token from %s was accepted", opts.tokenFrom))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		report("FAIL", "rate limit", fmt.Sprintf("GitHub answered /rate_limit with status %d", resp.StatusCode))
+		return exitFailure
+	}
+	var limits rateLimit
+	if err := json.NewDecoder(io.LimitReader(resp.Body, opts.maxBodySize)).Decode(&limits); err != nil {
+		report("FAIL", "rate limit", fmt.Sprintf("couldn't parse the response: %v", err))
+		return exitFailure
+	}
+	core := limits.Resources.Core
+	detail := fmt.Sprintf("%d of %d requests left, resets at %s", core.Remaining, core.Limit, time.Unix(core.Reset, 0).In(location(opts)).Format("15:04:05"))
+	if core.Remaining == 0 {
+		// Not fatal: it resolves itself once the window resets.
+		report("WARN", "rate limit", detail)
+	} else {
+		report("PASS", "rate limit", detail)
+	}
+	return exitOK
+}
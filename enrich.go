@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+const (
+	// enrichConcurrency bounds how many follow-up requests run at once.
+	enrichConcurrency = 4
+	// enrichRateFloor is how much rate limit follow-up requests leave
+	// untouched, so that enriching never starves the main fetch.
+	enrichRateFloor = 10
+)
+
+// followUp runs the extra per-item requests behind options such as
+// --enrich-commits. It caches by URL, bounds concurrency and stops once
+// the rate limit runs low. Failures are skipped rather than reported: the
+// extra detail is nice to have, not essential.
+type followUp struct {
+	opts options
+	sem  chan struct{}
+
+	mu        sync.Mutex
+	cache     map[string]json.RawMessage
+	remaining int // X-RateLimit-Remaining from the latest response, -1 if unknown
+}
+
+func newFollowUp(opts options) *followUp {
+	return &followUp{
+		opts:      opts,
+		sem:       make(chan struct{}, enrichConcurrency),
+		cache:     make(map[string]json.RawMessage),
+		remaining: -1,
+	}
+}
+
+// get decodes the JSON at apiURL into v, reporting whether it succeeded.
+func (f *followUp) get(ctx context.Context, apiURL string, v any) bool {
+	f.mu.Lock()
+	body, cached := f.cache[apiURL]
+	lowOnQuota := f.remaining >= 0 && f.remaining < enrichRateFloor
+	f.mu.Unlock()
+	if cached {
+		return json.Unmarshal(body, v) == nil
+	}
+	if lowOnQuota {
+		return false
+	}
+
+	f.sem <- struct{}{}
+	defer func() { <-f.sem }()
+
+	req, err := newRequest(ctx, apiURL, f.opts)
+	if err != nil {
+		return false
+	}
+	pg, err := apiClient(f.opts).Do(req)
+	header := pg.Header
+	var apiErr *activity.APIError
+	if errors.As(err, &apiErr) {
+		header = apiErr.Header
+	}
+	if remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil {
+		f.mu.Lock()
+		f.remaining = remaining
+		f.mu.Unlock()
+	}
+	if err != nil || json.Unmarshal(pg.Body, v) != nil {
+		return false
+	}
+	body = pg.Body
+
+	f.mu.Lock()
+	f.cache[apiURL] = body
+	f.mu.Unlock()
+	return true
+}
+
+// CommitStats is the line count of a commit, from the single-commit API.
+type CommitStats struct {
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+}
+
+// enrichCommits fills in CommitStats for the commits of every PushEvent by
+// asking the API for each commit. Commits it couldn't get are left out.
+func enrichCommits(ctx context.Context, events []Event, opts options) {
+	f := newFollowUp(opts)
+	base := strings.TrimSuffix(opts.baseURL, "/")
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex // guards the CommitStats maps
+	)
+	for i := range events {
+		if events[i].Type != "PushEvent" || events[i].Repo.Name == "" {
+			continue
+		}
+		for _, commit := range events[i].Payload.Commits {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var detail struct {
+					Stats CommitStats `json:"stats"`
+				}
+				apiURL := fmt.Sprintf("%s/repos/%s/commits/%s", base, events[i].Repo.Name, commit.SHA)
+				if !f.get(ctx, apiURL, &detail) {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if events[i].CommitStats == nil {
+					events[i].CommitStats = make(map[string]CommitStats)
+				}
+				events[i].CommitStats[commit.SHA] = detail.Stats
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// eventIssue returns the issue or pull request an event is about, and false
+// for events that aren't about one.
+func eventIssue(event Event) (Issue, bool) {
+	switch event.Type {
+	case "IssuesEvent", "IssueCommentEvent":
+		return event.Payload.Issue, true
+	case "PullRequestEvent", "PullRequestReviewEvent", "PullRequestReviewCommentEvent":
+		return event.Payload.PullRequest, true
+	}
+	return Issue{}, false
+}
+
+// resolveState sets CurrentState for the issue or pull request of every
+// event by asking the API for it. Only URLs under --base-url are followed,
+// so the token never goes anywhere else. Events it couldn't look up are
+// left alone.
+func resolveState(ctx context.Context, events []Event, opts options) {
+	f := newFollowUp(opts)
+	base := strings.TrimSuffix(opts.baseURL, "/") + "/"
+
+	var wg sync.WaitGroup
+	for i := range events {
+		issue, ok := eventIssue(events[i])
+		if !ok || !strings.HasPrefix(issue.URL, base) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var detail struct {
+				State  string `json:"state"`
+				Merged bool   `json:"merged"`
+			}
+			if f.get(ctx, issue.URL, &detail) && detail.State != "" {
+				events[i].CurrentState = detail.State
+				if detail.Merged {
+					events[i].CurrentState = "merged"
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// etagCacheFile holds the last response for each feed page, so that the
+// next request for it can be conditional. GitHub answers a conditional
+// request for an unchanged page with 304 Not Modified, which doesn't count
+// against the rate limit.
+const etagCacheFile = "etags.json"
+
+// maxETagEntries caps the cache; the entries fetched longest ago go first.
+const maxETagEntries = 200
+
+// etagEntry is a cached response and when it was fetched.
+type etagEntry struct {
+	ETag      string          `json:"etag"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Next      string          `json:"next,omitempty"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// etags is the ETag cache, read from etagCacheFile on first use and kept
+// in memory for the rest of the run, so that it is read and written once
+// rather than for every page. saveETags writes it back. Guarded by cacheMu.
+var etags struct {
+	entries map[string]etagEntry
+	loaded  bool
+	dirty   bool // changed since it was loaded or last saved
+}
+
+// loadETags returns the cached entries, reading them on the first call.
+// The caller must hold cacheMu.
+func loadETags() map[string]etagEntry {
+	if !etags.loaded {
+		etags.loaded = true
+		etags.entries = make(map[string]etagEntry)
+		if err := readCache(etagCacheFile, &etags.entries); err != nil {
+			etags.entries = make(map[string]etagEntry)
+		}
+	}
+	return etags.entries
+}
+
+// saveETags writes the cache back if storePage changed it, keeping only
+// the maxETagEntries fetched last. Like the rest of the cache it ignores
+// errors.
+func saveETags() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if !etags.dirty {
+		return
+	}
+	entries := etags.entries
+	if len(entries) > maxETagEntries {
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return entries[keys[i]].FetchedAt.Before(entries[keys[j]].FetchedAt)
+		})
+		for _, key := range keys[:len(keys)-maxETagEntries] {
+			delete(entries, key)
+		}
+	}
+	writeCache(etagCacheFile, entries)
+	etags.dirty = false
+}
+
+// etagKey is the cache key for apiURL. Authenticated responses may
+// include private events, so they are kept apart from anonymous ones.
+func etagKey(apiURL string, opts options) string {
+	if opts.token != "" {
+		return "auth " + apiURL
+	}
+	return apiURL
+}
+
+// cachedPage returns the cached response for apiURL if there is one and,
+// with --cache-max-age, it isn't older than that. An expired entry makes
+// the request unconditional, so the page is fetched in full, and so does
+// --no-cache. The cache is only an optimization, so errors reading it
+// count as a miss.
+func cachedPage(apiURL string, opts options) (etagEntry, bool) {
+	if opts.noCache {
+		return etagEntry{}, false
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	entry, ok := loadETags()[etagKey(apiURL, opts)]
+	if !ok || entry.ETag == "" {
+		return etagEntry{}, false
+	}
+	if opts.cacheMaxAge > 0 && now().Sub(entry.FetchedAt) > opts.cacheMaxAge {
+		return etagEntry{}, false
+	}
+	return entry, true
+}
+
+// storePage caches a response that came with an ETag, unless --no-cache,
+// to be written by the next saveETags.
+func storePage(apiURL, etag string, pg page, opts options) {
+	if etag == "" || opts.noCache || !json.Valid(pg.body) {
+		return
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	loadETags()[etagKey(apiURL, opts)] = etagEntry{ETag: etag, FetchedAt: now().UTC(), Next: pg.next, Body: pg.body}
+	etags.dirty = true
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// resetETags empties the in-memory ETag cache, as at the start of a run,
+// and points the cache at a fresh directory for the rest of the test.
+func resetETags(t *testing.T) {
+	t.Helper()
+	saved := cacheOverride
+	cacheOverride = t.TempDir()
+	etags.entries, etags.loaded, etags.dirty = nil, false, false
+	t.Cleanup(func() {
+		cacheOverride = saved
+		etags.entries, etags.loaded, etags.dirty = nil, false, false
+	})
+}
+
+func TestNotModifiedReturnsCachedPage(t *testing.T) {
+	resetETags(t)
+	const body = `[{"id":"1","type":"WatchEvent"}]`
+	var ifNoneMatch []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `W/"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `W/"v1"`)
+		w.Header().Set("Link", `<`+"http://"+r.Host+`/users/alice/events?page=2>; rel="next"`)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	apiURL := srv.URL + "/users/alice/events"
+	opts := options{maxBodySize: 1 << 20}
+
+	first, err := fetchPage(context.Background(), apiURL, "alice", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saveETags()
+	if _, err := os.Stat(filepath.Join(cacheOverride, etagCacheFile)); err != nil {
+		t.Fatalf("the ETag wasn't saved: %v", err)
+	}
+
+	// The next run starts with only what was saved on disk.
+	etags.entries, etags.loaded = nil, false
+	second, err := fetchPage(context.Background(), apiURL, "alice", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ifNoneMatch) != 2 || ifNoneMatch[0] != "" || ifNoneMatch[1] != `W/"v1"` {
+		t.Fatalf("If-None-Match headers sent: %q, want none and then the saved ETag", ifNoneMatch)
+	}
+	// The cache file is indented; the events must be the same.
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, second.body); err != nil || compacted.String() != body {
+		t.Errorf("304 gave body %s, want the cached %s", second.body, body)
+	}
+	if second.next != first.next || second.next == "" {
+		t.Errorf("304 gave next page %q, want the cached %q", second.next, first.next)
+	}
+
+	// An entry older than --cache-max-age makes the request unconditional.
+	saved := now
+	now = func() time.Time { return saved().Add(2 * time.Hour) }
+	t.Cleanup(func() { now = saved })
+	opts.cacheMaxAge = time.Hour
+	if _, err := fetchPage(context.Background(), apiURL, "alice", opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := ifNoneMatch[len(ifNoneMatch)-1]; got != "" {
+		t.Errorf("an expired entry still sent If-None-Match %q", got)
+	}
+}
+
+func TestETagCacheKeepsTokensApart(t *testing.T) {
+	resetETags(t)
+	var ifNoneMatch string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = r.Header.Get("If-None-Match")
+		w.Header().Set("ETag", `"anonymous"`)
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+	apiURL := srv.URL + "/users/alice/events"
+
+	if _, err := fetchPage(context.Background(), apiURL, "alice", options{maxBodySize: 1 << 20}); err != nil {
+		t.Fatal(err)
+	}
+	// An anonymous response has no private events, and must not stand in
+	// for the authenticated one.
+	if _, err := fetchPage(context.Background(), apiURL, "alice", options{maxBodySize: 1 << 20, token: "t0ken"}); err != nil {
+		t.Fatal(err)
+	}
+	if ifNoneMatch != "" {
+		t.Errorf("the authenticated request sent the anonymous ETag %q", ifNoneMatch)
+	}
+}
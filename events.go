@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+// formatEvent describes a single event as a short human-readable sentence.
+func formatEvent(event Event, opts options) string {
+	line := activity.Describe(event.Event, describeStyle(opts))
+	if !activity.Supported(event.Type) {
+		return line
+	}
+	if event.CurrentState != "" {
+		line += fmt.Sprintf(" [now: %s]", event.CurrentState)
+	}
+	if opts.explain {
+		line += " (" + activity.Explain(event.Type) + ")"
+	}
+	return line
+}
+
+// describeStyle is how the flags ask events to be described.
+func describeStyle(opts options) activity.Style {
+	return activity.Style{
+		RepoName:        func(repo Repo) string { return displayRepo(repo, opts) },
+		ShowSHA:         opts.showSHA,
+		DistinctCommits: opts.distinctCommits,
+		Verbose:         opts.verbose,
+	}
+}
+
+// unknownTypes returns, sorted and without repeats, the types of events
+// that would fall through to the generic sentence.
+func unknownTypes(events []Event) []string {
+	var unknown []string
+	for _, event := range events {
+		if !activity.Supported(event.Type) && !slices.Contains(unknown, event.Type) {
+			unknown = append(unknown, event.Type)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,41 @@
+package main
+
+import "errors"
+
+// Exit codes, so that scripts can tell failures apart without parsing
+// the error text.
+const (
+	exitOK          = 0
+	exitFailure     = 1 // anything not covered below
+	exitUsage       = 2 // bad flags or arguments
+	exitNotFound    = 3 // the user or organization doesn't exist
+	exitRateLimited = 4 // GitHub refused the request because of rate limiting
+	exitNetwork     = 5 // GitHub couldn't be reached
+	exitUnknownType = 6 // --strict met an event type without dedicated formatting
+)
+
+// exitError attaches an exit code to an error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode wraps err so that exitCode reports code for it.
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitCode is the code the process should exit with after err.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitFailure
+}
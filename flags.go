@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+// rawFlags holds the flags that validateFlags parses or checks before they
+// become options.
+type rawFlags struct {
+	columns     string
+	input       string
+	timezone    string
+	utc         bool
+	since       string
+	sinceDays   int
+	hideBots    bool
+	botPattern  string
+	redactRepos bool
+	theme       string
+}
+
+// validateFlags checks the parsed flags and fills in the options derived
+// from them. All the checks live here, in one place, so that conflicting
+// or out-of-range flags are always reported with a specific message
+// instead of one of them being silently ignored. The caller exits with
+// exitUsage on an error.
+func validateFlags(opts *options, raw rawFlags, usernames []string) error {
+	// Flags that can't be combined, each pair with the reason it's an error.
+	conflicts := []struct {
+		both bool
+		msg  string
+	}{
+		{opts.org != "" && len(usernames) > 0, "--org and a positional username are mutually exclusive."},
+		{opts.org != "" && opts.received, "--org and --received are mutually exclusive."},
+		{opts.userID != 0 && len(usernames) > 0, "--user-id and a positional username are mutually exclusive."},
+		{opts.userID != 0 && opts.org != "", "--user-id and --org are mutually exclusive."},
+		{opts.userID != 0 && opts.dryRun, "--user-id can't be combined with --dry-run, since finding the login takes a request."},
+		{raw.input != "" && (opts.userID != 0 || opts.trackIdentity || opts.enrichCommits || opts.resolveState),
+			"--input can't be combined with --user-id, --track-identity, --enrich-commits or --resolve-state, which look things up in the API."},
+		{raw.since != "" && raw.sinceDays != 0, "--since and --since-days are mutually exclusive."},
+		{raw.timezone != "" && raw.utc, "--timezone and --utc are mutually exclusive."},
+		{opts.absolute && opts.compactTime, "--absolute and --compact-time are mutually exclusive."},
+		{opts.countBy != "" && opts.reposSummary, "--count-by and --repos-summary are mutually exclusive."},
+		{opts.listRepos && (opts.countBy != "" || opts.reposSummary), "--list-repos can't be combined with --count-by or --repos-summary."},
+		{opts.chart && (opts.reposSummary || opts.listRepos), "--chart can't be combined with --repos-summary or --list-repos."},
+		{opts.format != "text" && (opts.countBy != "" || opts.reposSummary || opts.listRepos || opts.chart),
+			"--count-by (and the summary command), --repos-summary, --list-repos and --chart print text of their own and need --format text."},
+		{opts.compact && opts.groupBy != "", "--compact and --group-by are mutually exclusive."},
+		{opts.jsonBare && opts.format != "json", "--json-bare needs --format json."},
+		{opts.flatten && opts.format != "json" && opts.format != "csv", "--flatten needs --format json or csv."},
+		{opts.appendOutput && opts.output == "", "--append needs an --output file."},
+		{opts.stream && opts.format != "text", "--stream needs --format text; the other formats need the whole feed."},
+		{opts.stream && (opts.groupBy != "" || opts.compact || opts.countBy != "" || opts.reposSummary || opts.listRepos || opts.chart || opts.headOnly || opts.merge || opts.strict || opts.raw),
+			"--stream can't be combined with --group-by, --compact, --count-by, --repos-summary, --list-repos, --chart, --head-only, --merge, --strict or --raw, which need the whole feed."},
+		{!opts.watch && (flagGiven("interval") || flagGiven("interval-jitter")), "--interval and --interval-jitter need --watch or the watch command."},
+		{opts.watch && opts.format != "text", "watch needs --format text."},
+		{opts.watch && (opts.countBy != "" || opts.reposSummary || opts.listRepos || opts.chart || opts.compact || opts.groupBy != "" || opts.merge || opts.stream || opts.tui),
+			"watch can't be combined with --count-by, --repos-summary, --list-repos, --chart, --compact, --group-by, --merge, --stream or --tui."},
+	}
+	for _, c := range conflicts {
+		if c.both {
+			return fmt.Errorf("%s", c.msg)
+		}
+	}
+
+	if _, ok := formats[opts.format]; !ok {
+		return fmt.Errorf("Unknown format '%s'. Use %s.", opts.format, strings.Join(formatNames, ", "))
+	}
+	if opts.flatten {
+		// Flattened columns are key paths, checked against the events
+		// themselves; by default every key is a column.
+		if flagGiven("columns") {
+			opts.columns = columnList(raw.columns)
+		}
+	} else {
+		cols, err := parseColumns(raw.columns)
+		if err != nil {
+			return err
+		}
+		// Several users' rows share one CSV, so each says whose it is.
+		if opts.format == "csv" && len(usernames) > 1 {
+			cols = withActorColumn(cols)
+		}
+		opts.columns = cols
+	}
+	if opts.countBy != "" && !slices.Contains(countDimensions, opts.countBy) {
+		return fmt.Errorf("Unknown --count-by dimension '%s'. Use %s.", opts.countBy, strings.Join(countDimensions, ", "))
+	}
+	if opts.groupBy != "" && opts.groupBy != "repo" && opts.groupBy != "day" {
+		return fmt.Errorf("Unknown --group-by value '%s'. Use repo or day.", opts.groupBy)
+	}
+
+	theme, ok := themes[raw.theme]
+	if !ok {
+		return fmt.Errorf("Unknown theme '%s'. Use %s.", raw.theme, strings.Join(themeNames, ", "))
+	}
+	// NO_COLOR (https://no-color.org) wins over any theme.
+	if len(theme) > 0 && os.Getenv("NO_COLOR") == "" {
+		opts.theme = theme
+	}
+
+	if raw.since != "" {
+		t, err := time.Parse(time.RFC3339, raw.since)
+		if err != nil {
+			return fmt.Errorf("--since must be an RFC3339 time such as 2024-05-01T00:00:00Z. Reason: %v", err)
+		}
+		opts.since = t
+	}
+	if raw.sinceDays < 0 {
+		return fmt.Errorf("--since-days can't be negative.")
+	}
+	if raw.sinceDays > 0 {
+		opts.since = now().AddDate(0, 0, -raw.sinceDays)
+	}
+	if raw.utc {
+		opts.timezone = time.UTC
+	}
+	if raw.timezone != "" {
+		loc, err := time.LoadLocation(raw.timezone)
+		if err != nil {
+			return fmt.Errorf("Unknown time zone '%s'. Use an IANA name such as America/New_York or UTC.", raw.timezone)
+		}
+		opts.timezone = loc
+	}
+
+	if opts.userID < 0 {
+		return fmt.Errorf("--user-id must be a positive account ID.")
+	}
+	if opts.maxBodySize <= 0 {
+		return fmt.Errorf("--max-body-size must be positive.")
+	}
+	if opts.watch && opts.interval <= 0 {
+		return fmt.Errorf("--interval must be positive.")
+	}
+	if opts.intervalJitter < 0 {
+		return fmt.Errorf("--interval-jitter can't be negative.")
+	}
+	if opts.pageDelay < 0 {
+		return fmt.Errorf("--page-delay can't be negative.")
+	}
+	if opts.cacheMaxAge < 0 {
+		return fmt.Errorf("--cache-max-age can't be negative.")
+	}
+	if err := checkTypes("--type", opts.types); err != nil {
+		return err
+	}
+	if err := checkTypes("--hide-type", opts.hideTypes); err != nil {
+		return err
+	}
+	if opts.concurrency <= 0 {
+		return fmt.Errorf("--concurrency must be positive.")
+	}
+	if opts.limit < 0 || opts.limit > activity.MaxEvents {
+		return fmt.Errorf("--limit must be between 0 and %d, which is all GitHub keeps.", activity.MaxEvents)
+	}
+	if opts.retryEmpty < 0 || opts.retryDelay < 0 {
+		return fmt.Errorf("--retry-empty and --retry-delay can't be negative.")
+	}
+	if opts.sampleRate <= 0 || opts.sampleRate > 1 {
+		return fmt.Errorf("--sample-rate must be greater than 0 and at most 1.")
+	}
+
+	if raw.botPattern != "" {
+		re, err := regexp.Compile(raw.botPattern)
+		if err != nil {
+			return fmt.Errorf("--bot-pattern isn't a valid regular expression. Reason: %v", err)
+		}
+		opts.hideBots = re
+	} else if raw.hideBots {
+		opts.hideBots = defaultBotPattern
+	}
+
+	opts.source = apiSource{}
+	if raw.input != "" {
+		src, err := newFileSource(raw.input)
+		if err != nil {
+			return fmt.Errorf("Could not open --input. Reason: %v", err)
+		}
+		opts.source = src
+	}
+	opts.redact = newRedactor(raw.redactRepos)
+	return nil
+}
+
+// checkTypes rejects event types in a --type or --hide-type list that
+// GitHub doesn't have, which is almost always a typo that would silently
+// filter out everything.
+func checkTypes(name, list string) error {
+	for _, eventType := range strings.Split(list, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType == "" || activity.Known(eventType) {
+			continue
+		}
+		known := activity.KnownTypes()
+		hint := ""
+		if i := slices.IndexFunc(known, func(t string) bool { return strings.EqualFold(t, eventType) }); i >= 0 {
+			hint = fmt.Sprintf(" (did you mean %s?)", known[i])
+		}
+		var plain []string
+		for _, t := range known {
+			if !activity.Supported(t) {
+				plain = append(plain, t)
+			}
+		}
+		note := "."
+		if len(plain) > 0 {
+			note = fmt.Sprintf("; all but %s get dedicated formatting.", strings.Join(plain, ", "))
+		}
+		return fmt.Errorf("Unknown event type '%s' for %s%s. GitHub's event types are %s%s", eventType, name, hint, strings.Join(known, ", "), note)
+	}
+	return nil
+}
+
+// listValue is a flag that may be given several times, each time with one
+// or more comma-separated items, collecting them all into one
+// comma-separated list.
+type listValue struct {
+	list *string
+}
+
+func (v listValue) String() string {
+	if v.list == nil {
+		return ""
+	}
+	return *v.list
+}
+
+func (v listValue) Set(value string) error {
+	if *v.list != "" {
+		value = *v.list + "," + value
+	}
+	*v.list = value
+	return nil
+}
+
+// flagGiven reports whether the flag called name was on the command line,
+// as opposed to keeping its default.
+func flagGiven(name string) bool {
+	given := false
+	flag.Visit(func(f *flag.Flag) {
+		given = given || f.Name == name
+	})
+	return given
+}
+
+// secondsValue is a duration flag that also takes a plain number of
+// seconds, so that --interval 30 means 30s.
+type secondsValue struct {
+	d *time.Duration
+}
+
+func (v secondsValue) String() string {
+	if v.d == nil {
+		return ""
+	}
+	return v.d.String()
+}
+
+func (v secondsValue) Set(value string) error {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		*v.d = time.Duration(seconds) * time.Second
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.New("neither a number of seconds nor a duration such as 30s")
+	}
+	*v.d = d
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateFlagsConflicts(t *testing.T) {
+	valid := func() (options, rawFlags) {
+		return options{format: "text", maxBodySize: 1 << 20, concurrency: 4, sampleRate: 1},
+			rawFlags{columns: defaultColumns, theme: "mono"}
+	}
+	tests := []struct {
+		name      string
+		set       func(*options, *rawFlags)
+		usernames []string
+		want      string // a part of the error; "" when the flags are fine
+	}{
+		{"defaults", func(*options, *rawFlags) {}, []string{"alice"}, ""},
+		{"org and username", func(o *options, _ *rawFlags) { o.org = "github" }, []string{"alice"}, "--org and a positional username"},
+		{"org and received", func(o *options, _ *rawFlags) { o.org, o.received = "github", true }, nil, "--org and --received"},
+		{"user-id and org", func(o *options, _ *rawFlags) { o.userID, o.org = 583231, "github" }, nil, "--user-id and --org"},
+		{"since and since-days", func(_ *options, r *rawFlags) { r.since, r.sinceDays = "2024-05-01T00:00:00Z", 3 }, nil, "--since and --since-days"},
+		{"timezone and utc", func(_ *options, r *rawFlags) { r.timezone, r.utc = "Europe/Berlin", true }, nil, "--timezone and --utc"},
+		{"input and enrich-commits", func(o *options, r *rawFlags) { r.input, o.enrichCommits = "feed.json", true }, nil, "--input can't be combined"},
+		{"count-by and repos-summary", func(o *options, _ *rawFlags) { o.countBy, o.reposSummary = "type", true }, nil, "--count-by and --repos-summary"},
+		{"count-by with json", func(o *options, _ *rawFlags) { o.countBy, o.format = "type", "json" }, nil, "need --format text"},
+		{"compact and group-by", func(o *options, _ *rawFlags) { o.compact, o.groupBy = true, "repo" }, nil, "--compact and --group-by"},
+		{"json-bare with csv", func(o *options, _ *rawFlags) { o.jsonBare, o.format = true, "csv" }, nil, "--json-bare needs --format json"},
+		{"flatten with table", func(o *options, _ *rawFlags) { o.flatten, o.format = true, "table" }, nil, "--flatten needs --format json or csv"},
+		{"append without output", func(o *options, _ *rawFlags) { o.appendOutput = true }, nil, "--append needs an --output file"},
+		{"stream with merge", func(o *options, _ *rawFlags) { o.stream, o.merge = true, true }, nil, "--stream can't be combined"},
+		{"watch with json", func(o *options, _ *rawFlags) { o.watch, o.interval, o.format = true, time.Minute, "json" }, nil, "watch needs --format text"},
+		{"unknown format", func(o *options, _ *rawFlags) { o.format = "yaml" }, nil, "Unknown format 'yaml'"},
+		{"unknown group-by", func(o *options, _ *rawFlags) { o.groupBy = "week" }, nil, "Unknown --group-by value 'week'"},
+		{"negative since-days", func(_ *options, r *rawFlags) { r.sinceDays = -1 }, nil, "--since-days can't be negative"},
+		{"limit above 300", func(o *options, _ *rawFlags) { o.limit = 301 }, nil, "--limit must be between 0 and 300"},
+		{"typo in type", func(o *options, _ *rawFlags) { o.types = "pushevent" }, nil, "did you mean PushEvent?"},
+		{"typo in a type without formatting", func(o *options, _ *rawFlags) { o.hideTypes = "pullrequestreviewthreadevent" }, nil, "did you mean PullRequestReviewThreadEvent?"},
+		{"type without formatting", func(o *options, _ *rawFlags) { o.types = "PullRequestReviewThreadEvent,DiscussionEvent" }, []string{"alice"}, ""},
+		{"made-up type", func(o *options, _ *rawFlags) { o.types = "PushEvents" }, nil, "Unknown event type 'PushEvents'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, raw := valid()
+			tt.set(&opts, &raw)
+			err := validateFlags(&opts, raw, tt.usernames)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Fatalf("validateFlags() = %v, want no error", err)
+			case tt.want != "" && err == nil:
+				t.Fatalf("validateFlags() accepted the flags, want an error containing %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Fatalf("validateFlags() = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
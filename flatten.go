@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// flattenEvents turns each event into a single-level object keyed by dotted
+// paths such as "repo.name" and "payload.issue.title", for consumers that
+// can't deal with nesting. Array elements get their index as a path
+// segment ("payload.commits.0.sha"); empty objects and arrays are left out.
+// The keys are derived from the JSON encoding, so they always match the
+// nested output.
+func flattenEvents(events []Event) ([]map[string]any, error) {
+	flat := make([]map[string]any, 0, len(events))
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber() // keep IDs exact rather than turning them into floats
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		out := make(map[string]any)
+		flattenValue("", v, out)
+		flat = append(flat, out)
+	}
+	return flat, nil
+}
+
+// flattenValue stores v in out under prefix, descending into objects and
+// arrays.
+func flattenValue(prefix string, v any, out map[string]any) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			flattenValue(join(key), value, out)
+		}
+	case []any:
+		for i, value := range v {
+			flattenValue(join(strconv.Itoa(i)), value, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
+
+// writeFlatCSV writes flattened events as CSV. The columns are the ones
+// given with --columns or, by default, every key any event has, sorted.
+// existing is the header of the file the rows are appended to, which is
+// then left out and, without --columns, gives the columns.
+func writeFlatCSV(w io.Writer, events []Event, columns, existing []string) error {
+	flat, err := flattenEvents(events)
+	if err != nil {
+		return err
+	}
+	// Rows appended to a file go under its header.
+	if len(columns) == 0 {
+		columns = existing
+	}
+	if len(columns) == 0 {
+		keys := make(map[string]bool)
+		for _, event := range flat {
+			for key := range event {
+				keys[key] = true
+			}
+		}
+		for key := range keys {
+			columns = append(columns, key)
+		}
+		sort.Strings(columns)
+	}
+
+	cw := csv.NewWriter(w)
+	if existing == nil {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+	for _, event := range flat {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			if value, ok := event[column]; ok && value != nil {
+				row[i] = fmt.Sprint(value)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
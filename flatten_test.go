@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+func TestFlattenEvents(t *testing.T) {
+	parsed, _, err := activity.ParseEvents([]byte(`[{
+		"id": "2489651045",
+		"type": "PushEvent",
+		"actor": {"login": "octocat"},
+		"repo": {"id": 1296269, "name": "octocat/Hello-World"},
+		"payload": {
+			"ref": "refs/heads/main",
+			"size": 2,
+			"commits": [
+				{"sha": "6dcb09b", "message": "Fix all the bugs", "author": {"name": "Mona"}},
+				{"sha": "7fd1a60", "message": "Update README"}
+			]
+		},
+		"created_at": "2024-05-01T12:00:00Z"
+	}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []Event{{Event: parsed[0], CurrentState: "merged"}}
+
+	flat, err := flattenEvents(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := flat[0]
+	want := map[string]any{
+		"id":                        "2489651045",
+		"type":                      "PushEvent",
+		"actor.login":               "octocat",
+		"repo.id":                   json.Number("1296269"),
+		"repo.name":                 "octocat/Hello-World",
+		"payload.ref":               "refs/heads/main",
+		"payload.size":              json.Number("2"),
+		"payload.commits.0.sha":     "6dcb09b",
+		"payload.commits.0.message": "Fix all the bugs",
+		"payload.commits.1.sha":     "7fd1a60",
+		"created_at":                "2024-05-01T12:00:00Z",
+		"current_state":             "merged",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %#v, want %#v", key, got[key], value)
+		}
+	}
+	for key, value := range got {
+		switch value.(type) {
+		case map[string]any, []any:
+			t.Errorf("%s is still nested: %#v", key, value)
+		}
+	}
+	if _, ok := got["commit_stats"]; ok {
+		t.Error("an empty commit_stats was kept")
+	}
+
+	var b strings.Builder
+	if err := writeFlatCSV(&b, events, []string{"repo.name", "payload.commits.1.sha", "payload.missing"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "repo.name,payload.commits.1.sha,payload.missing\noctocat/Hello-World,7fd1a60,\n"; b.String() != want {
+		t.Errorf("CSV:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// renderer writes events in one --format. Everything else it needs, such
+// as the feed's heading or the options, is given when it is built.
+//
+// It is activity.Formatter for the command's own Event: the line counts
+// of --enrich-commits and the state of --resolve-state are in the output,
+// and activity.Event doesn't carry them, so the command can't hand its
+// events to an activity.Formatter without losing them.
+type renderer interface {
+	Render(w io.Writer, events []Event) error
+}
+
+// renderFunc adapts a function to the renderer interface.
+type renderFunc func(w io.Writer, events []Event) error
+
+// Render calls fn(w, events).
+func (fn renderFunc) Render(w io.Writer, events []Event) error {
+	return fn(w, events)
+}
+
+// outputFormat is one --format value.
+type outputFormat struct {
+	label string // names the output in errors, e.g. "JSON output"
+	new   func(f feed, opts options) renderer
+}
+
+// formatNames lists the --format values in the order help and errors show
+// them.
+var formatNames = []string{"text", "json", "html", "atom", "csv", "table", "prometheus", "markdown", "standup"}
+
+// formats maps every --format value to its renderer. A new format only
+// needs an entry here and in formatNames, plus one in combiners if its
+// output is a single document.
+var formats = map[string]outputFormat{
+	"text": {"output", func(f feed, opts options) renderer {
+		return textRenderer{feed: f, opts: opts}
+	}},
+	"json": {"JSON output", func(f feed, opts options) renderer {
+		return renderFunc(func(w io.Writer, events []Event) error {
+			return writeJSON(w, f.login, events, f.truncated, f.parseErrors, opts)
+		})
+	}},
+	"html": {"HTML output", func(f feed, opts options) renderer {
+		return renderFunc(func(w io.Writer, events []Event) error {
+			return writeHTML(w, []section{{feed: f, events: events}}, opts)
+		})
+	}},
+	"atom": {"Atom output", func(f feed, opts options) renderer {
+		return renderFunc(func(w io.Writer, events []Event) error {
+			return writeAtom(w, []section{{feed: f, events: events}}, opts)
+		})
+	}},
+	"csv": {"CSV output", func(f feed, opts options) renderer {
+		return renderFunc(func(w io.Writer, events []Event) error {
+			if opts.flatten {
+				return writeFlatCSV(w, events, opts.columns, opts.csvHeader)
+			}
+			return writeCSV(w, events, opts)
+		})
+	}},
+	"table": {"the table", func(f feed, opts options) renderer {
+		return renderFunc(func(w io.Writer, events []Event) error {
+			return writeEventTable(w, events, opts)
+		})
+	}},
+	"prometheus": {"Prometheus metrics", func(f feed, opts options) renderer {
+		return renderFunc(func(w io.Writer, events []Event) error {
+			return writePrometheus(w, []section{{feed: f, events: events}}, opts)
+		})
+	}},
+	"markdown": {"Markdown output", func(f feed, opts options) renderer {
+		return renderFunc(func(w io.Writer, events []Event) error {
+			return writeMarkdown(w, f.heading, events, f.showActor, opts)
+		})
+	}},
+	"standup": {"the standup list", func(f feed, opts options) renderer {
+		return renderFunc(func(w io.Writer, events []Event) error {
+			return writeStandup(w, f.heading, events, opts)
+		})
+	}},
+}
+
+// section is one feed's part of a combined document: the feed and those
+// of its events that passed the filters, or the error it failed with. A
+// failed section's feed only has its login.
+type section struct {
+	feed   feed
+	events []Event
+	err    error
+}
+
+// combiners writes several users' feeds as one document, for the formats
+// where a document per user, one after another, wouldn't be valid: an
+// HTML fragment, an Atom feed, a Prometheus exposition, which allows each
+// metric's HELP and TYPE only once, a JSON value or a CSV with one header. Only JSON can say
+// which users failed; the others leave failed sections out.
+var combiners = map[string]func(w io.Writer, sections []section, opts options) error{
+	"html":       writeHTML,
+	"atom":       writeAtom,
+	"prometheus": writePrometheus,
+	"json":       writeJSONSections,
+	"csv":        writeCSVSections,
+}
+
+// combineSections returns the logins of the sections that didn't fail and
+// their events in one timeline. several reports whether there was more
+// than one of them, in which case the events are sorted newest first
+// across feeds.
+func combineSections(sections []section) (logins []string, events []Event, several bool) {
+	for _, s := range sections {
+		if s.err != nil {
+			continue
+		}
+		logins = append(logins, s.feed.login)
+		events = append(events, s.events...)
+	}
+	several = len(logins) > 1
+	if several {
+		sortTimeline(events)
+	}
+	return logins, events, several
+}
+
+// textRenderer is the default human-readable output: a heading and one
+// "- ..." line per event.
+type textRenderer struct {
+	feed feed
+	opts options
+}
+
+func (t textRenderer) Render(w io.Writer, events []Event) error {
+	f, opts := t.feed, t.opts
+	if opts.headOnly {
+		// A bare line, nothing else, for status bars and shell prompts.
+		for _, event := range events {
+			line := formatEvent(event, opts)
+			if f.showActor && event.Actor.Login != "" {
+				line = event.Actor.Login + " " + lowerFirst(line)
+			}
+			fmt.Fprintln(w, line)
+		}
+		return nil
+	}
+	if len(f.parseErrors) > 0 && !opts.quiet {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d event(s) for %s that couldn't be parsed (see parse_errors in --format json).\n", len(f.parseErrors), f.heading)
+	}
+	fmt.Fprintf(w, "Recent Activity for %s:\n\n", f.heading)
+
+	// Tell "nothing happened" apart from "nothing matched", since the
+	// latter usually means a filter was too narrow.
+	if len(events) == 0 {
+		if !opts.quiet {
+			if len(f.events) == 0 {
+				fmt.Fprintln(w, "No recent public activity found.")
+			} else {
+				fmt.Fprintf(w, "No events matched the given filters (%d fetched).\n", len(f.events))
+			}
+		}
+		return nil
+	}
+
+	if opts.compact {
+		printCompact(w, events, opts)
+		return nil
+	}
+
+	// Process and display each event, under a header per group if asked
+	if opts.groupBy != "" {
+		for i, g := range groupEvents(events, opts.groupBy, location(opts)) {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s\n", g.Key)
+			printEvents(w, g.Events, f.showActor, opts)
+		}
+		return nil
+	}
+	printEvents(w, events, f.showActor, opts)
+	return nil
+}
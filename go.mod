@@ -1,3 +1,7 @@
 module github.com/ichsand
 
 go 1.22.2
+
+require golang.org/x/term v0.24.0
+
+require golang.org/x/sys v0.25.0 // indirect
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden from the current output")
+
+// TestGoldenOutput runs the recorded feed in testdata/feed.json through
+// the whole pipeline, from --input to the formatter, and compares the
+// output with testdata/<name>.golden. After an intended change to the
+// output, regenerate the files with
+//
+//	go test -run TestGoldenOutput -update
+//
+// and review the diff.
+func TestGoldenOutput(t *testing.T) {
+	saved := now
+	now = func() time.Time { return time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = saved })
+	t.Setenv("NO_COLOR", "1")
+
+	// Every format once with the default flags, plus the text variants
+	// that change the wording of a line.
+	type goldenCase struct {
+		name   string
+		format string
+		set    func(*options)
+	}
+	var tests []goldenCase
+	for _, format := range formatNames {
+		tests = append(tests, goldenCase{format, format, func(*options) {}})
+	}
+	tests = append(tests,
+		goldenCase{"text-verbose", "text", func(o *options) { o.verbose = true }},
+		goldenCase{"text-compact-time", "text", func(o *options) { o.compactTime = true }},
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options{
+				format:      tt.format,
+				baseURL:     activity.DefaultBaseURL,
+				maxBodySize: 1 << 20,
+				concurrency: 1,
+				sampleRate:  1,
+				noCache:     true,
+			}
+			tt.set(&opts)
+			raw := rawFlags{columns: defaultColumns, theme: "mono", input: "testdata/feed.json", utc: true}
+			if err := validateFlags(&opts, raw, []string{"octocat"}); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			f, err := fetchFeed(ctx, "octocat", opts, newProgress(nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			if err := showFeed(ctx, &b, f, opts); err != nil {
+				t.Fatal(err)
+			}
+			compareGolden(t, filepath.Join("testdata", tt.name+".golden"), b.Bytes())
+		})
+	}
+}
+
+// compareGolden fails the test when got differs from the golden file, or
+// with -update writes got to it instead.
+func compareGolden(t *testing.T, golden string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run go test -update if the change is intended)\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
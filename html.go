@@ -0,0 +1,68 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// htmlTemplate renders the events as a list for embedding in a web page.
+// html/template escapes every field, so a title such as
+// `<script>alert(1)</script>` comes out as text rather than markup.
+var htmlTemplate = template.Must(template.New("activity").Parse(`<ul class="github-activity">
+{{- range .}}
+  <li class="event {{.Class}}">{{.Before}}{{if .URL}}<a href="{{.URL}}">{{.Repo}}</a>{{end}}{{.After}}</li>
+{{- end}}
+</ul>
+`))
+
+// htmlItem is one <li> of the HTML output. The event's sentence is split
+// around the repository name so that the name can become a link.
+type htmlItem struct {
+	Class  string
+	Before string
+	Repo   string
+	URL    string
+	After  string
+}
+
+// writeHTML renders events as a <ul> of <li> items with repository links.
+// Several sections become one list, newest first, with each item naming
+// who did it.
+func writeHTML(w io.Writer, sections []section, opts options) error {
+	_, events, several := combineSections(sections)
+	items := make([]htmlItem, 0, len(events))
+	for _, event := range events {
+		line := formatEvent(event, opts)
+		if several && event.Actor.Login != "" {
+			line = event.Actor.Login + " " + lowerFirst(line)
+		}
+		item := htmlItem{Class: eventClass(event.Type), Before: line}
+		repo := displayRepo(event.Repo, opts)
+		if before, after, ok := strings.Cut(line, repo); ok && event.Repo.Name != "" {
+			item.Before = before
+			item.Repo = repo
+			item.URL = repoURL(event.Repo.Name, opts)
+			item.After = after
+		}
+		items = append(items, item)
+	}
+	return htmlTemplate.Execute(w, items)
+}
+
+// eventClass turns an event type into a CSS class, e.g. "PullRequestEvent"
+// becomes "event-pull-request".
+func eventClass(eventType string) string {
+	name := strings.TrimSuffix(eventType, "Event")
+
+	var b strings.Builder
+	b.WriteString("event")
+	for i, r := range name {
+		if unicode.IsUpper(r) || i == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+func TestWriteHTMLEscapesTitles(t *testing.T) {
+	event := Event{Event: activity.Event{
+		ID:   "1",
+		Type: "IssuesEvent",
+		Repo: activity.Repo{Name: "octocat/hello"},
+		Payload: activity.Payload{
+			Action: "opened",
+			Issue:  activity.Issue{Title: `<script>alert("x")</script> & <b>bold</b>`},
+		},
+	}}
+	var b strings.Builder
+	if err := writeHTML(&b, []section{{feed: feed{login: "octocat"}, events: []Event{event}}}, options{baseURL: activity.DefaultBaseURL}); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+
+	for _, raw := range []string{"<script>", "</script>", "<b>", `"x"`, " & "} {
+		if strings.Contains(got, raw) {
+			t.Errorf("output contains %q unescaped:\n%s", raw, got)
+		}
+	}
+	for _, escaped := range []string{"&lt;script&gt;", "&amp;", "&lt;b&gt;bold&lt;/b&gt;"} {
+		if !strings.Contains(got, escaped) {
+			t.Errorf("output lacks %q:\n%s", escaped, got)
+		}
+	}
+	want := `<li class="event event-issues">Opened an issue in <a href="https://github.com/octocat/hello">octocat/hello</a>`
+	if !strings.Contains(got, want) {
+		t.Errorf("output lacks the linked repository %q:\n%s", want, got)
+	}
+}
+
+func TestEventClass(t *testing.T) {
+	tests := map[string]string{
+		"PushEvent":                     "event-push",
+		"PullRequestEvent":              "event-pull-request",
+		"PullRequestReviewCommentEvent": "event-pull-request-review-comment",
+	}
+	for eventType, want := range tests {
+		if got := eventClass(eventType); got != want {
+			t.Errorf("eventClass(%q) = %q, want %q", eventType, got, want)
+		}
+	}
+}
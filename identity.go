@@ -0,0 +1,128 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// identityCacheFile maps logins to the numeric account IDs they resolved
+// to on earlier --track-identity and --user-id runs.
+const identityCacheFile = "identities.json"
+
+// account is the part of /users/{username} needed to tell accounts apart.
+type account struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+}
+
+// trackIdentity looks up the account behind username and compares it with
+// what earlier runs saw, writing a warning to w when the login now belongs
+// to a different account or the account has been seen under another
+// login. Usernames can be released and claimed by someone else, so for
+// long-running monitoring the ID is the reliable identity.
+func trackIdentity(ctx context.Context, w io.Writer, username string, opts options) error {
+	apiURL := fmt.Sprintf("%s/users/%s", strings.TrimSuffix(opts.baseURL, "/"), url.PathEscape(username))
+	pg, err := fetchPageRetrying(ctx, apiURL, fmt.Sprintf("GitHub user '%s'", username), opts)
+	if err != nil {
+		return err
+	}
+	var current account
+	if err := json.Unmarshal(pg.body, &current); err != nil {
+		return fmt.Errorf("Failed to parse the user from the GitHub API. Reason: %v", err)
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	known := make(map[string]int64) // lowercased login -> account ID
+	if err := readCache(identityCacheFile, &known); err != nil {
+		return fmt.Errorf("Failed to read the identity cache. Reason: %v", err)
+	}
+
+	login := strings.ToLower(current.Login)
+	if id, ok := known[login]; ok && id != current.ID {
+		fmt.Fprintf(w, "Warning: '%s' is now a different account (id %d, previously %d).\n", current.Login, current.ID, id)
+	}
+	for oldLogin, id := range known {
+		if id == current.ID && oldLogin != login {
+			fmt.Fprintf(w, "Note: account %d, previously seen as '%s', is now '%s'.\n", id, oldLogin, current.Login)
+			delete(known, oldLogin)
+		}
+	}
+
+	known[login] = current.ID
+	if err := writeCache(identityCacheFile, known); err != nil {
+		return fmt.Errorf("Failed to write the identity cache. Reason: %v", err)
+	}
+	return nil
+}
+
+// resolveUserID looks up the current login of the account with the given
+// numeric ID, for --user-id. Unlike a login, the ID survives renames. The
+// mapping is remembered in the identity cache, and a rename since the
+// last lookup is pointed out on stderr.
+func resolveUserID(ctx context.Context, id int64, opts options) (string, error) {
+	apiURL := fmt.Sprintf("%s/user/%d", strings.TrimSuffix(opts.baseURL, "/"), id)
+	pg, err := fetchPageRetrying(ctx, apiURL, fmt.Sprintf("GitHub account %d", id), opts)
+	if err != nil {
+		return "", err
+	}
+	var current account
+	if err := json.Unmarshal(pg.body, &current); err != nil || current.Login == "" {
+		return "", fmt.Errorf("Failed to parse the account from the GitHub API. Reason: %v", cmp.Or(err, errors.New("no login")))
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	// The cache only makes the rename note possible; failing to use it
+	// doesn't stop the run.
+	known := make(map[string]int64) // lowercased login -> account ID
+	if readCache(identityCacheFile, &known) != nil {
+		return current.Login, nil
+	}
+	login := strings.ToLower(current.Login)
+	for oldLogin, knownID := range known {
+		if knownID == id && oldLogin != login {
+			if !opts.quiet {
+				fmt.Fprintf(os.Stderr, "Note: account %d, previously seen as '%s', is now '%s'.\n", id, oldLogin, current.Login)
+			}
+			delete(known, oldLogin)
+		}
+	}
+	known[login] = id
+	writeCache(identityCacheFile, known)
+	return current.Login, nil
+}
+
+// needsTokenOwner reports whether feedURL depends on whom the token
+// belongs to: only a user's own feed includes private events. Replayed
+// --input pages don't come from the API, so there is nothing to look up.
+func needsTokenOwner(opts options) bool {
+	if _, replayed := opts.source.(*fileSource); replayed {
+		return false
+	}
+	return opts.token != "" && opts.org == "" && !opts.publicEvents
+}
+
+// tokenOwner returns the login of the account the token belongs to, or ""
+// if it can't be found out, e.g. because the token may not read the user.
+func tokenOwner(ctx context.Context, opts options) string {
+	apiURL := strings.TrimSuffix(opts.baseURL, "/") + "/user"
+	pg, err := fetchPageRetrying(ctx, apiURL, "the token's user", opts)
+	if err != nil {
+		return ""
+	}
+	var owner account
+	if json.Unmarshal(pg.body, &owner) != nil {
+		return ""
+	}
+	return owner.Login
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+// jsonVersion is bumped whenever the envelope changes in a way that could
+// break consumers.
+const jsonVersion = 1
+
+// jsonEnvelope wraps the events printed by --format json with enough
+// metadata for long-lived consumers to detect format changes.
+type jsonEnvelope struct {
+	Version   int    `json:"version"`
+	Username  string `json:"username"`
+	FetchedAt string `json:"fetched_at"`
+	Count     int    `json:"count"`
+	Truncated bool   `json:"truncated"` // fetching stopped before the end of the feed
+	Events    any    `json:"events"`    // []Event, or flattened objects with --flatten
+
+	// ParseErrors lists events left out because they didn't match the
+	// expected schema. It is only present when there were any.
+	ParseErrors []activity.ParseError `json:"parse_errors,omitempty"`
+
+	// Errors lists the users whose feed failed, when several users share
+	// one document. It is only present when there were any.
+	Errors []jsonUserError `json:"errors,omitempty"`
+}
+
+// jsonUserError is one failed user in the errors of a combined document.
+type jsonUserError struct {
+	Username string `json:"username"`
+	jsonErrorDetail
+}
+
+// writeJSON encodes events to w, either wrapped in the versioned envelope
+// or, with --json-bare, as a plain array.
+func writeJSON(w io.Writer, username string, events []Event, truncated bool, parseErrors []activity.ParseError, opts options) error {
+	return encodeJSON(w, jsonEnvelope{Username: username, Truncated: truncated, ParseErrors: parseErrors}, events, opts)
+}
+
+// writeJSONSections encodes several users' feeds as one document: one
+// envelope whose username lists them all, comma-separated, and whose
+// events are a single timeline. The users whose feed failed are listed
+// under errors, so the output stays one JSON value to parse. A bare array
+// has nowhere to put them; they are only reported on stderr then.
+func writeJSONSections(w io.Writer, sections []section, opts options) error {
+	var envelope jsonEnvelope
+	var usernames []string
+	for _, s := range sections {
+		usernames = append(usernames, s.feed.login)
+		if s.err != nil {
+			envelope.Errors = append(envelope.Errors, jsonUserError{s.feed.login, jsonErrorDetail{Code: errorCodes[exitCode(s.err)], Message: s.err.Error()}})
+			continue
+		}
+		envelope.Truncated = envelope.Truncated || s.feed.truncated
+		envelope.ParseErrors = append(envelope.ParseErrors, s.feed.parseErrors...)
+	}
+	envelope.Username = strings.Join(usernames, ",")
+	_, events, _ := combineSections(sections)
+	return encodeJSON(w, envelope, events, opts)
+}
+
+// encodeJSON fills in the rest of envelope for events and encodes it, or
+// with --json-bare just the events.
+func encodeJSON(w io.Writer, envelope jsonEnvelope, events []Event, opts options) error {
+	var out any = events
+	if events == nil {
+		out = []Event{}
+	}
+	if opts.flatten {
+		flat, err := flattenEvents(events)
+		if err != nil {
+			return err
+		}
+		out = flat
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if opts.jsonBare {
+		return enc.Encode(out)
+	}
+	envelope.Version = jsonVersion
+	envelope.FetchedAt = now().UTC().Format(time.RFC3339)
+	envelope.Count = len(events)
+	envelope.Events = out
+	return enc.Encode(envelope)
+}
+
+// errorCodes names each exit code in the error object of --format json.
+var errorCodes = map[int]string{
+	exitFailure:     "failure",
+	exitUsage:       "usage",
+	exitNotFound:    "not_found",
+	exitRateLimited: "rate_limited",
+	exitNetwork:     "network",
+	exitUnknownType: "unknown_type",
+}
+
+// jsonError is what --format json prints instead of the envelope when a
+// feed fails, so that a consumer always gets JSON to parse:
+// {"error": {"code": "not_found", "message": "..."}}.
+type jsonError struct {
+	Error jsonErrorDetail `json:"error"`
+}
+
+type jsonErrorDetail struct {
+	Code    string `json:"code"` // from errorCodes; the exit code says the same
+	Message string `json:"message"`
+}
+
+// writeJSONError encodes err as a jsonError.
+func writeJSONError(w io.Writer, err error) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonError{Error: jsonErrorDetail{Code: errorCodes[exitCode(err)], Message: err.Error()}})
+}
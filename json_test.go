@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureOutput runs fn with os.Stdout and os.Stderr sent to files and
+// returns what was written to each.
+func captureOutput(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	dir := t.TempDir()
+	files := make([]*os.File, 2)
+	for i, name := range []string{"stdout", "stderr"} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		files[i] = f
+	}
+	savedOut, savedErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = files[0], files[1]
+	defer func() { os.Stdout, os.Stderr = savedOut, savedErr }()
+	fn()
+
+	var written [2]string
+	for i, f := range files {
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		written[i] = string(b)
+	}
+	return written[0], written[1]
+}
+
+func TestJSONForSeveralUsersIsOneDocument(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch userInPath(r.URL.Path) {
+		case "alice":
+			w.Write([]byte(`[{"id":"11","type":"WatchEvent","actor":{"login":"alice"},"repo":{"name":"o/r"},"created_at":"2024-05-01T09:00:00Z"}]`))
+		case "carol":
+			w.Write([]byte(`[{"id":"31","type":"WatchEvent","actor":{"login":"carol"},"repo":{"name":"o/r"},"created_at":"2024-05-01T10:00:00Z"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	for _, bare := range []bool{false, true} {
+		opts := options{
+			baseURL:     srv.URL,
+			source:      apiSource{},
+			format:      "json",
+			jsonBare:    bare,
+			concurrency: 2,
+			maxBodySize: 1 << 20,
+			sampleRate:  1,
+			noCache:     true,
+			timezone:    time.UTC,
+		}
+		var code int
+		stdout, stderr := captureOutput(t, func() {
+			code = runWithDeadline([]string{"alice", "bob", "carol"}, opts)
+		})
+		if code != exitNotFound {
+			t.Errorf("--json-bare=%v: exit code %d, want %d for bob", bare, code, exitNotFound)
+		}
+		if !strings.Contains(stderr, "bob") {
+			t.Errorf("--json-bare=%v: bob's failure isn't on stderr: %q", bare, stderr)
+		}
+
+		if bare {
+			var events []Event
+			if err := json.Unmarshal([]byte(stdout), &events); err != nil {
+				t.Fatalf("--json-bare output isn't one JSON value: %v\n%s", err, stdout)
+			}
+			if got := eventIDs(events); !slices.Equal(got, []string{"31", "11"}) {
+				t.Errorf("--json-bare events %v, want one timeline, newest first", got)
+			}
+			continue
+		}
+		var envelope struct {
+			Username string          `json:"username"`
+			Count    int             `json:"count"`
+			Events   []Event         `json:"events"`
+			Errors   []jsonUserError `json:"errors"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &envelope); err != nil {
+			t.Fatalf("output isn't one JSON value: %v\n%s", err, stdout)
+		}
+		if envelope.Username != "alice,bob,carol" || envelope.Count != 2 {
+			t.Errorf("username %q and count %d, want alice,bob,carol and 2", envelope.Username, envelope.Count)
+		}
+		if got := eventIDs(envelope.Events); !slices.Equal(got, []string{"31", "11"}) {
+			t.Errorf("events %v, want one timeline, newest first", got)
+		}
+		if len(envelope.Errors) != 1 || envelope.Errors[0].Username != "bob" || envelope.Errors[0].Code != "not_found" {
+			t.Errorf("errors = %+v, want bob's not_found", envelope.Errors)
+		}
+	}
+}
@@ -1,121 +1,1362 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/ichsand/pkg/activity"
 )
 
-// Event represents a single event from the GitHub API.
-// We only define the fields we need to parse.
+// Event is an event as the command handles it: the event from the API,
+// plus what the follow-up lookups of --resolve-state and --enrich-commits
+// found out about it. Those are the command's own, so they live here
+// rather than in pkg/activity.
 type Event struct {
-	Type    string  `json:"type"`
-	Repo    Repo    `json:"repo"`
-	Payload Payload `json:"payload"`
+	activity.Event
+	// CurrentState is the state of the event's issue or pull request now,
+	// from --resolve-state: "open", "closed" or "merged".
+	CurrentState string `json:"current_state,omitempty"`
+	// CommitStats holds the line counts of a push's commits by SHA, from
+	// --enrich-commits.
+	CommitStats map[string]CommitStats `json:"commit_stats,omitempty"`
 }
 
-// Repo contains information about the repository.
-type Repo struct {
-	Name string `json:"name"`
+// The other event types live in pkg/activity, which other programs can
+// import; the command refers to them by their short names.
+type (
+	Actor            = activity.Actor
+	Org              = activity.Org
+	Repo             = activity.Repo
+	Issue            = activity.Issue
+	PullRequestLinks = activity.PullRequestLinks
+	CommitAuthor     = activity.CommitAuthor
+	Forkee           = activity.Forkee
+	Commit           = activity.Commit
+	Payload          = activity.Payload
+	Wiki             = activity.Wiki
+	Comment          = activity.Comment
+	Release          = activity.Release
+)
+
+// options holds the settings parsed from the command-line flags.
+type options struct {
+	quiet          bool
+	types          string         // comma-separated allow-list of event types
+	hideTypes      string         // comma-separated event types to drop after the allow-list
+	onlyAction     string         // comma-separated payload actions to keep, e.g. opened
+	repos          string         // comma-separated owner/name allow-list of repositories
+	orgFilter      string         // comma-separated organizations whose repositories to keep
+	hideBots       *regexp.Regexp // drop events by actors matching this, nil keeps them
+	org            string         // fetch the organization's feed instead of a user's
+	userID         int64          // resolve the login from this account ID first
+	received       bool           // fetch the events the user received rather than performed
+	showActor      bool
+	format         string   // "text", "json", "html", "atom", "csv", "table", "prometheus", "markdown" or "standup"
+	output         string   // file to write to, stdout when empty
+	appendOutput   bool     // add to the output file rather than replacing it
+	csvHeader      []string // the header of the CSV file appended to, if it has one
+	jsonBare       bool     // emit a bare JSON array instead of the versioned envelope
+	deadline       time.Duration
+	showSHA        bool
+	explain        bool       // end lines with what the event type means
+	sampleRate     float64    // fraction of events to keep, 1 keeps everything
+	seed           int64      // seeds --sample-rate; 0 picks one from the clock
+	merge          bool       // interleave several users into one timeline
+	baseURL        string     // API root, e.g. https://github.example.com/api/v3 for Enterprise
+	source         pageSource // where feed pages come from: the API or --input
+	webURL         string     // web root for links, derived from baseURL when empty
+	dryRun         bool
+	maxRedirects   int            // 0 doesn't follow redirects at all
+	countBy        string         // print a frequency table by this dimension instead of the events
+	groupBy        string         // list events under a header per repo or day
+	timezone       *time.Location // zone to show dates in, nil for local time
+	maxBodySize    int64          // largest response body accepted, in bytes
+	listTypes      bool
+	publicOnly     bool              // drop events on private repositories
+	publicEvents   bool              // use the /public feed even for the token's owner
+	token          string            // from --token, GITHUB_TOKEN or ~/.netrc; sent as a bearer token
+	tokenFrom      string            // where token came from, for error messages
+	wrap           bool              // word-wrap lines to the terminal width
+	theme          map[string]string // per-type line colors from --theme; nil for none
+	since          time.Time         // drop events older than this; zero keeps everything
+	reposSummary   bool              // print per-repo counts instead of the events
+	listRepos      bool              // print the repos touched instead of the events
+	chart          bool              // draw the counts as a bar chart
+	humanizeCounts bool              // abbreviate summary counts, e.g. 1.2k
+	// githubActions wraps output in workflow commands for the Actions log.
+	githubActions   bool
+	apiVersion      string        // sent as X-GitHub-Api-Version
+	strict          bool          // fail on event types without dedicated formatting
+	statsLine       bool          // report pages, events and time on stderr at the end (--debug does too)
+	shortRepo       bool          // drop the owner from repos the queried user owns
+	onlyOwned       bool          // keep only events on repos the queried user owns
+	enrichCommits   bool          // look up +/- line counts for every pushed commit
+	resolveState    bool          // look up the current state of every issue and pull request
+	trackIdentity   bool          // warn when a login changes hands or an account is renamed
+	columns         []string      // csv and table columns, in order
+	flatten         bool          // dotted keys instead of nested objects in json and csv
+	tui             bool          // browse the events interactively
+	headOnly        bool          // print only the most recent matching event
+	retryEmpty      int           // times to ask again when a feed comes back empty
+	limit           int           // events to fetch, paging as needed; 0 for one page
+	concurrency     int           // feeds fetched at once when there are several users
+	verbose         bool          // list the commits of each push
+	distinctCommits bool          // count only the commits new to the repository
+	compact         bool          // one symbol per event, one line per day
+	compactTime     bool          // prefix lines with HH:MM today, the date before
+	absolute        bool          // end lines with the RFC3339 time instead of "2 hours ago"
+	stream          bool          // print text output page by page as it arrives
+	noMentionEscape bool          // leave @mentions live in markdown output
+	onlyNew         bool          // hide events shown by earlier --only-new runs
+	debug           bool          // log every request and response to stderr
+	raw             bool          // print the API responses as received
+	redact          *redactor     // --redact-repos placeholders for debug and raw output
+	hideSelfAuthor  bool          // with verbose, leave out authors who are the pusher
+	retryDelay      time.Duration // wait between those attempts
+	pageDelay       time.Duration // wait between the pages of a feed
+	watch           bool          // keep checking for new events, for the watch command
+	interval        time.Duration // wait between those checks
+	intervalJitter  time.Duration // random change of up to this much to each interval
+	cacheMaxAge     time.Duration // cached pages older than this are fetched unconditionally
+	noCache         bool          // skip the page cache: unconditional requests, nothing stored
+	// queried holds the logins whose feed is being shown. It is set per
+	// feed by showFeed rather than by a flag.
+	queried []string
+	// tokenOwner is the login the token belongs to, looked up at the
+	// start of the run; "" without a token or if the lookup failed.
+	tokenOwner string
+	// onPage, when set, is called with each page's events as soon as the
+	// page is parsed. --stream uses it to print before the feed is done.
+	onPage func(f feed, events []Event) error
 }
 
-// Issue contains details about an issue or pull request.
-type Issue struct {
-	Title string `json:"title"`
+// now is the clock used for relative dates such as --since-days. It is a
+// variable so that it can be pinned when reproducing output.
+var now = time.Now
+
+func main() {
+	var opts options
+	var raw rawFlags
+	flag.BoolVar(&opts.quiet, "quiet", false, "suppress progress output and informational messages")
+	flag.Var(listValue{&opts.types}, "type", "only show these event `types` (comma-separated or repeated, e.g. PushEvent,IssuesEvent)")
+	flag.StringVar(&opts.onlyAction, "only-action", "", "only show events with these payload actions, e.g. opened,reopened (comma-separated, case-insensitive)")
+	flag.Var(listValue{&opts.hideTypes}, "hide-type", "hide these event `types` (comma-separated or repeated, e.g. WatchEvent)")
+	flag.BoolVar(&raw.hideBots, "hide-bots", false, "hide events by bot accounts such as dependabot[bot]")
+	flag.StringVar(&raw.botPattern, "bot-pattern", "", "with --hide-bots, a regular expression for bot logins instead of the [bot] suffix (implies --hide-bots)")
+	flag.StringVar(&opts.orgFilter, "org-filter", "", "only show events on repositories of these organizations (comma-separated)")
+	flag.StringVar(&opts.repos, "repo", "", "only show events on these repositories (comma-separated owner/name, case-insensitive)")
+	flag.BoolVar(&opts.publicEvents, "public-events", false, "always use the public events feed, even for the token's owner")
+	flag.BoolVar(&opts.publicOnly, "public-only", false, "hide events on private repositories (only matters with a token)")
+	flag.Int64Var(&opts.userID, "user-id", 0, "show the activity of the account with this numeric ID, whatever its current login")
+	flag.StringVar(&opts.org, "org", "", "show the public activity of an organization instead of a user")
+	flag.BoolVar(&opts.received, "received", false, "show events the user received (activity on watched repos and followed users)")
+	flag.BoolVar(&opts.showActor, "show-actor", false, "prefix each line with the login of the account that acted")
+	flag.StringVar(&opts.format, "format", "text", "output format: text, json, html, atom, csv, table, prometheus, markdown or standup")
+	flag.StringVar(&raw.columns, "columns", defaultColumns, "with --format csv or table, the columns to show, in order")
+	flag.BoolVar(&opts.flatten, "flatten", false, "with --format json or csv, use flat keys such as payload.issue.title instead of nested objects")
+	flag.StringVar(&raw.input, "input", "", "read the events from this saved response, or from page1.json, page2.json, ... in this directory, instead of the API")
+	flag.StringVar(&opts.output, "output", "", "write the output to this file instead of stdout")
+	flag.BoolVar(&opts.appendOutput, "append", false, "with --output, add to the end of the file instead of replacing it")
+	flag.BoolVar(&opts.jsonBare, "json-bare", false, "with --format json, print a bare array of events instead of the envelope")
+	flag.BoolVar(&opts.explain, "explain", false, "end each line with a short explanation of what that kind of event means")
+	flag.BoolVar(&opts.showSHA, "show-sha", false, "show the abbreviated before..head commit range of pushes")
+	flag.Float64Var(&opts.sampleRate, "sample-rate", 1.0, "randomly keep only this fraction of events, e.g. 0.5")
+	flag.Int64Var(&opts.seed, "seed", 0, "random seed for --sample-rate, for reproducible output (0 means random)")
+	flag.BoolVar(&opts.merge, "merge", false, "with several usernames, merge their events into one chronological timeline")
+	flag.StringVar(&opts.webURL, "web-url", "", "root of the GitHub web interface for links (default derived from --base-url)")
+	flag.StringVar(&opts.baseURL, "base-url", activity.DefaultBaseURL, "GitHub API base URL (for GitHub Enterprise use https://<host>/api/v3)")
+	flag.StringVar(&opts.apiVersion, "api-version", activity.DefaultAPIVersion, "GitHub REST API version to request")
+	flag.IntVar(&opts.maxRedirects, "max-redirects", 10, "follow at most this many redirects (0 means don't follow any)")
+	flag.StringVar(&opts.countBy, "count-by", "", "print event counts grouped by "+strings.Join(countDimensions, ", ")+" instead of the events")
+	flag.StringVar(&opts.groupBy, "group-by", "", "list events under a header per repo or day (newest day first)")
+	flag.StringVar(&raw.timezone, "timezone", "", "show dates in this IANA time zone, e.g. America/New_York (default local time)")
+	flag.BoolVar(&raw.utc, "utc", false, "show dates in UTC; short for --timezone UTC")
+	flag.Int64Var(&opts.maxBodySize, "max-body-size", 5<<20, "refuse API responses larger than this many bytes")
+	flag.StringVar(&raw.since, "since", "", "only show events at or after this RFC3339 time, e.g. 2024-05-01T00:00:00Z")
+	flag.IntVar(&raw.sinceDays, "since-days", 0, "only show events from the last N days")
+	flag.StringVar(&raw.theme, "theme", "dark", "colors for the event types when printing to a terminal: "+strings.Join(themeNames, ", ")+" (NO_COLOR turns them off)")
+	flag.BoolVar(&opts.wrap, "wrap", false, "word-wrap long lines to the terminal width (only when printing to a terminal)")
+	flag.BoolVar(&opts.humanizeCounts, "humanize-counts", false, "abbreviate counts in --count-by and --repos-summary, e.g. 1.2k")
+	flag.BoolVar(&opts.chart, "chart", false, "draw the event counts per type (or per --count-by dimension) as a bar chart instead of listing the events")
+	flag.BoolVar(&opts.listRepos, "list-repos", false, "print just the repositories the events are on, one per line, sorted")
+	flag.BoolVar(&opts.reposSummary, "repos-summary", false, "print each repository with its event count and last activity instead of the events")
+	flag.BoolVar(&opts.githubActions, "github-actions", false, "format output for a GitHub Actions log (on by default when GITHUB_ACTIONS=true)")
+	flag.BoolVar(&opts.strict, "strict", false, "fail instead of printing a generic line for event types the tool doesn't know")
+	flag.BoolVar(&opts.statsLine, "stats-line", false, "print how many events and pages were fetched, and how long it took, to stderr")
+	flag.BoolVar(&opts.onlyOwned, "only-owned", false, "only show events on repos owned by the queried user")
+	flag.BoolVar(&opts.shortRepo, "short-repo", false, "show just the repo name, without the owner, for repos owned by the queried user")
+	flag.BoolVar(&opts.enrichCommits, "enrich-commits", false, "look up the added/deleted line counts of pushed commits (one extra API request per commit)")
+	flag.BoolVar(&opts.resolveState, "resolve-state", false, "look up the current state of each issue and pull request (one request each) and show it, e.g. [now: closed]")
+	flag.BoolVar(&opts.trackIdentity, "track-identity", false, "remember each user's account ID and warn if the username is renamed or taken over")
+	flag.BoolVar(&opts.tui, "tui", false, "browse the events in an interactive, scrollable list (falls back to plain output without a terminal)")
+	flag.BoolVar(&opts.headOnly, "head-only", false, "print only the most recent event that matches the filters, on a line of its own")
+	flag.BoolVar(&opts.headOnly, "latest", false, "alias for --head-only")
+	flag.BoolVar(&opts.watch, "watch", false, "keep checking for new events and print them as they appear, like the watch command")
+	opts.interval = defaultWatchInterval
+	flag.Var(secondsValue{&opts.interval}, "interval", "with --watch, how long to wait between checks, e.g. 30s or just 30")
+	flag.DurationVar(&opts.intervalJitter, "interval-jitter", 0, "with --watch, wait up to this much more or less than --interval, at random, e.g. 10s (uses --seed)")
+	flag.IntVar(&opts.concurrency, "concurrency", defaultConcurrency, "with several users, fetch at most this many feeds at once")
+	flag.IntVar(&opts.limit, "limit", 0, "fetch up to this many events, following the feed's pages (GitHub keeps at most 300; 0 means one page)")
+	flag.IntVar(&opts.retryEmpty, "retry-empty", 0, "when a feed comes back empty, ask again up to this many times before reporting no activity")
+	flag.DurationVar(&opts.retryDelay, "retry-delay", 2*time.Second, "with --retry-empty, how long to wait between attempts")
+	flag.BoolVar(&opts.noMentionEscape, "no-mention-escape", false, "with --format markdown, leave @mentions as they are instead of wrapping them in backticks")
+	flag.BoolVar(&opts.debug, "debug", false, "log every request and response (with the token redacted) to stderr")
+	flag.BoolVar(&opts.raw, "raw", false, "print the API responses as received instead of formatting them")
+	flag.BoolVar(&raw.redactRepos, "redact-repos", false, "with --debug or --raw, replace repository names with placeholders")
+	flag.BoolVar(&opts.onlyNew, "only-new", false, "only show events that no earlier --only-new run has shown")
+	flag.BoolVar(&opts.stream, "stream", false, "with --format text, print each page's events as soon as it arrives instead of after the whole feed")
+	flag.BoolVar(&opts.absolute, "absolute", false, "end each line with the RFC3339 time of the event instead of how long ago it was")
+	flag.BoolVar(&opts.compactTime, "compact-time", false, "start each line with the time for events from today and the date for older ones")
+	flag.BoolVar(&opts.compact, "compact", false, "show one letter per event (P push, I issue, ...), one line per day")
+	flag.BoolVar(&opts.verbose, "verbose", false, "list the commits of each push with their message and author")
+	flag.BoolVar(&opts.distinctCommits, "distinct-commits", false, "count only the commits of a push that are new to the repository (distinct_size) instead of all of them (size)")
+	flag.BoolVar(&opts.hideSelfAuthor, "hide-self-author", false, "with --verbose, don't name the author of commits made by the user who pushed them")
+	flag.DurationVar(&opts.cacheMaxAge, "cache-max-age", 0, "fetch pages cached longer ago than this in full instead of asking whether they changed, e.g. 1h (0 means never)")
+	flag.BoolVar(&opts.noCache, "no-cache", false, "fetch every page in full instead of asking whether it changed since the cached copy, and don't cache it")
+	cacheDirFlag := flag.String("cache-dir", "", "keep the cache in this directory (default: the user cache directory, or $GITHUB_ACTIVITY_CACHE_DIR)")
+	tokenFlag := flag.String("token", "", "send requests with this token instead of GITHUB_TOKEN's (note that other users can see it in the process list)")
+	noNetrc := flag.Bool("no-netrc", false, "don't read a token from ~/.netrc when GITHUB_TOKEN isn't set")
+	doctor := flag.Bool("doctor", false, "check the connection to the API and the token, then exit")
+	flag.BoolVar(&opts.listTypes, "list-types", false, "list the event types with dedicated formatting and exit")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "print the request that would be sent and exit without sending it")
+	flag.DurationVar(&opts.pageDelay, "page-delay", 0, "wait this long between the pages of a feed, e.g. 500ms (longer when the rate limit runs low)")
+	flag.DurationVar(&opts.deadline, "deadline", 0, "give up on the whole run after this long, e.g. 30s (0 means no limit)")
+	command, args := splitSubcommand(os.Args[1:])
+	subcommands[command].flags(&opts)
+	flag.Usage = usage
+	usernames := parseArgs(args)
+	switch {
+	case *tokenFlag != "":
+		opts.token, opts.tokenFrom = *tokenFlag, "--token"
+	case os.Getenv("GITHUB_TOKEN") != "":
+		opts.token, opts.tokenFrom = os.Getenv("GITHUB_TOKEN"), "GITHUB_TOKEN"
+	case !*noNetrc:
+		if opts.token = netrcToken(opts.baseURL); opts.token != "" {
+			opts.tokenFrom = "~/.netrc"
+		}
+	}
+	opts.githubActions = opts.githubActions || os.Getenv("GITHUB_ACTIONS") == "true"
+
+	if opts.listTypes {
+		for _, eventType := range activity.SupportedTypes() {
+			fmt.Println(eventType)
+		}
+		return
+	}
+
+	// splitSubcommand only took a command off the front of the arguments.
+	explicit := len(args) < len(os.Args)-1
+	if err := checkSubcommandPlace(explicit, usernames); err != nil {
+		printError(os.Stdout, withExitCode(exitUsage, err), opts)
+		os.Exit(exitUsage)
+	}
+	if err := validateFlags(&opts, raw, usernames); err != nil {
+		printError(os.Stdout, withExitCode(exitUsage, err), opts)
+		os.Exit(exitUsage)
+	}
+	// The cache only backs optional features, so a directory that can't
+	// be written to turns it off instead of failing the run.
+	cacheOverride = cmp.Or(*cacheDirFlag, os.Getenv("GITHUB_ACTIVITY_CACHE_DIR"))
+	if opts.onlyNew || opts.trackIdentity {
+		if dir, err := checkCacheDir(); err != nil {
+			if !opts.quiet {
+				fmt.Fprintf(os.Stderr, "Warning: the cache directory %s can't be written to, so caching is disabled. Reason: %v\n", cmp.Or(dir, "(unknown)"), err)
+			}
+			cacheDisabled = true
+		}
+	}
+	// These formats are one document per run, which appending turns into
+	// several documents in one file.
+	if opts.appendOutput && slices.Contains([]string{"json", "html", "atom", "prometheus"}, opts.format) && !opts.quiet {
+		fmt.Fprintf(os.Stderr, "Warning: with --append, each run adds another %s document; the file won't parse as a single one.\n", opts.format)
+	}
+
+	if *doctor {
+		ctx := context.Background()
+		if opts.deadline > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.deadline)
+			defer cancel()
+		}
+		os.Exit(runDoctor(ctx, os.Stdout, opts))
+	}
+	// An organization feed takes no username; everything else needs at least one.
+	if opts.org != "" {
+		os.Exit(runWithDeadline([]string{""}, opts))
+	}
+
+	// The account's login is only known once runWithDeadline looks it up.
+	if opts.userID != 0 {
+		os.Exit(runWithDeadline(nil, opts))
+	}
+
+	// Check if a username was provided as a command-line argument
+	if len(usernames) < 1 {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	os.Exit(runWithDeadline(usernames, opts))
 }
 
-// Forkee contains information about the forked repository.
-type Forkee struct {
-	FullName string `json:"full_name"`
+// usage prints how to run the tool, named after however it was invoked.
+func usage() {
+	name := filepath.Base(os.Args[0])
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [<command>] <username> [<username>...] [flags]\n", name)
+	fmt.Fprintf(out, "       %s [<command>] --org <organization> [flags]\n\n", name)
+	subcommandUsage(out)
+	fmt.Fprintf(out, "Flags:\n")
+	flag.PrintDefaults()
 }
 
-// Payload contains event-specific details.
-type Payload struct {
-	Action      string `json:"action"`
-	RefType     string `json:"ref_type"`
-	Commits     []any  `json:"commits"` // We only need the count, so the type doesn't matter.
-	Issue       Issue  `json:"issue"`
-	Forkee      Forkee `json:"forkee"`
-	PullRequest Issue  `json:"pull_request"`
+// parseArgs parses args, the command line after any subcommand, and
+// returns the usernames. Unlike
+// flag.Parse alone, flags may also follow the usernames.
+func parseArgs(args []string) []string {
+	flag.CommandLine.Parse(args)
+	var usernames []string
+	for args := flag.Args(); len(args) > 0; args = flag.Args() {
+		usernames = append(usernames, args[0])
+		flag.CommandLine.Parse(args[1:])
+	}
+	return usernames
 }
 
-func main() {
-	// Check if a username was provided as a command-line argument
-	if len(os.Args) != 2 {
-		fmt.Println("Usage: go run github_activity.go <username>")
-		os.Exit(1)
+// runWithDeadline bounds everything the run does, however many requests
+// that turns out to be, by the --deadline flag. It returns the exit code;
+// with several users, that of the first one that failed.
+func runWithDeadline(usernames []string, opts options) int {
+	ctx := context.Background()
+	if opts.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.deadline)
+		defer cancel()
+	}
+
+	if opts.dryRun {
+		// The dry run sends nothing, so it can't look up the token's owner
+		// and shows both candidate feeds instead.
+		for _, username := range usernames {
+			if err := dryRun(ctx, os.Stdout, username, opts); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return exitUsage
+			}
+		}
+		return exitOK
+	}
+	// The ETag cache is kept in memory while the feeds are fetched.
+	defer saveETags()
+	if opts.userID != 0 {
+		login, err := resolveUserID(ctx, opts.userID, opts)
+		if err != nil {
+			printError(os.Stdout, err, opts)
+			return exitCode(err)
+		}
+		usernames = []string{login}
+	}
+	if needsTokenOwner(opts) {
+		opts.tokenOwner = tokenOwner(ctx, opts)
 	}
+	if opts.tui && canRunTUI() {
+		return runTUI(ctx, usernames, opts)
+	}
+	// One spinner covers every fetch: the fetches run concurrently, and
+	// several spinners would fight over the same line.
+	// Streamed output goes to the terminal while pages are still being
+	// fetched, so the spinner would draw over it.
+	prog := newProgress(newSpinner(os.Stderr, !opts.quiet && !opts.debug && !opts.stream && isTerminal(os.Stderr)))
+	defer prog.done()
+	if opts.statsLine || opts.debug {
+		// Deferred before anything is printed so it comes last, on stderr
+		// where it can't get mixed into piped output.
+		defer prog.writeStats(os.Stderr)
+	}
+
+	var out io.Writer = os.Stdout
+	if opts.output != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if opts.appendOutput {
+			flags = os.O_RDWR | os.O_CREATE | os.O_APPEND
+		}
+		file, err := os.OpenFile(opts.output, flags, 0o644)
+		if err != nil {
+			prog.done()
+			printError(os.Stdout, fmt.Errorf("Could not open the --output file. Reason: %v", err), opts)
+			return exitFailure
+		}
+		defer file.Close()
+		// A CSV file that already has rows already has its header, and the
+		// rows appended must have the same columns.
+		if opts.appendOutput && opts.format == "csv" {
+			if opts.csvHeader, err = readCSVHeader(file); err != nil {
+				err = fmt.Errorf("Could not read the header of the --output file. Reason: %v", err)
+			} else {
+				err = checkCSVHeader(opts.columns, opts.csvHeader)
+			}
+			if err != nil {
+				prog.done()
+				printError(os.Stdout, err, opts)
+				return exitFailure
+			}
+		}
+		out = file
+	}
+
+	if opts.watch {
+		prog.done()
+		return runWatch(ctx, out, usernames, opts)
+	}
+	// Formats whose output is a single document get one for all users,
+	// which is already a merged timeline.
+	if combine, ok := combiners[opts.format]; ok && len(usernames) > 1 && !opts.raw {
+		return getCombinedActivity(ctx, out, usernames, combine, opts, prog)
+	}
+	if opts.merge && len(usernames) > 1 {
+		return getMergedActivity(ctx, out, usernames, opts, prog)
+	}
+
+	// Streamed sections are written as they arrive, so users are fetched
+	// one after another instead of concurrently.
+	codes := make([]int, len(usernames))
+	if opts.stream {
+		for i, username := range usernames {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			codes[i] = getGithubActivity(ctx, out, username, opts, prog)
+		}
+		return reportFailures(usernames, codes, opts)
+	}
+
+	// Each user's section is rendered into its own buffer and the buffers
+	// are written out whole, in argument order, so sections never
+	// interleave however the fetches finish. At most --concurrency feeds
+	// are fetched at once, since a burst of requests is what trips
+	// GitHub's secondary rate limits.
+	outputs := make([]bytes.Buffer, len(usernames))
+	sem := make(chan struct{}, opts.concurrency)
+	var wg sync.WaitGroup
+	for i, username := range usernames {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			codes[i] = getGithubActivity(ctx, &outputs[i], username, opts, prog)
+		}(i, username)
+	}
+	wg.Wait()
+	prog.done()
+
+	for i := range outputs {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		out.Write(outputs[i].Bytes())
+	}
+	return reportFailures(usernames, codes, opts)
+}
 
-	githubUsername := os.Args[1]
-	getGithubActivity(githubUsername)
+// reportFailures returns the exit code of a run over several users: that
+// of the first one that failed, or exitOK. A failed user doesn't stop the
+// others, and its error is in its own section, so with more than one user
+// the failures are also summed up on stderr, where they can't get lost in
+// the output.
+func reportFailures(usernames []string, codes []int, opts options) int {
+	code := exitOK
+	var failed []string
+	for i, c := range codes {
+		if c == exitOK {
+			continue
+		}
+		if code == exitOK {
+			code = c
+		}
+		failed = append(failed, cmp.Or(usernames[i], opts.org))
+	}
+	if len(failed) > 0 && len(usernames) > 1 && !opts.quiet {
+		fmt.Fprintf(os.Stderr, "Warning: %d of %d users failed: %s.\n", len(failed), len(usernames), strings.Join(failed, ", "))
+	}
+	return code
 }
-func getGithubActivity(username string) {
-	// Construct the API URL
-	apiURL := fmt.Sprintf("https://api.github.com/users/%s/events", username)
 
-	// Make the HTTP GET request
-	resp, err := http.Get(apiURL)
+// feed is the result of fetching one user's or organization's events.
+type feed struct {
+	login     string // the user or organization queried
+	heading   string // shown in the "Recent Activity for ..." line
+	events    []Event
+	truncated bool // fetching stopped before the end of the feed
+	showActor bool // the actor varies from event to event
+
+	parseErrors []activity.ParseError // events skipped because they didn't parse
+	raw         [][]byte              // response bodies as received, for --raw
+}
+
+// getGithubActivity fetches one feed and writes it, or the error, to w.
+func getGithubActivity(ctx context.Context, w io.Writer, username string, opts options, prog *progress) int {
+	// In an Actions log each feed gets its own collapsible group.
+	if opts.githubActions {
+		fmt.Fprintf(w, "::group::GitHub activity for %s\n", escapeWorkflowData(cmp.Or(username, opts.org)))
+		defer fmt.Fprintln(w, "::endgroup::")
+	}
+
+	if opts.trackIdentity && username != "" {
+		if err := trackIdentity(ctx, os.Stderr, username, opts); err != nil {
+			printError(w, err, opts)
+			return exitCode(err)
+		}
+	}
+
+	var stream *textStream
+	if opts.stream {
+		stream = &textStream{ctx: ctx, w: w, opts: opts}
+		opts.onPage = stream.page
+	}
+	f, err := fetchFeed(ctx, username, opts, prog)
 	if err != nil {
-		fmt.Printf("Error: Could not reach GitHub API. Reason: %v\n", err)
-		return
+		printError(w, err, opts)
+		return exitCode(err)
+	}
+	if stream != nil {
+		err = stream.finish(f)
+	} else {
+		err = showFeed(ctx, w, f, opts)
 	}
-	defer resp.Body.Close()
+	if err != nil {
+		printError(w, err, opts)
+		return exitCode(err)
+	}
+	return exitOK
+}
 
-	// Handle non-200 status codes
-	if resp.StatusCode == 404 {
-		fmt.Printf("Error: Could not find GitHub user '%s'.\n", username)
-		return
+// getCombinedActivity writes several users' feeds as one document with
+// combine. A user whose fetch fails is reported on stderr, where the error
+// can't break the document, and passed to combine as a failed section.
+// The document is written even when every user failed, so that whatever
+// reads it still gets one, if an empty one.
+func getCombinedActivity(ctx context.Context, w io.Writer, usernames []string, combine func(io.Writer, []section, options) error, opts options, prog *progress) int {
+	feeds := make([]feed, len(usernames))
+	errs := make([]error, len(usernames))
+	sem := make(chan struct{}, opts.concurrency)
+	var wg sync.WaitGroup
+	for i, username := range usernames {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if opts.trackIdentity {
+				if errs[i] = trackIdentity(ctx, os.Stderr, username, opts); errs[i] != nil {
+					return
+				}
+			}
+			feeds[i], errs[i] = fetchFeed(ctx, username, opts, prog)
+		}(i, username)
 	}
-	if resp.StatusCode != 200 {
-		fmt.Printf("Error: Received status code %d from GitHub API.\n", resp.StatusCode)
-		return
+	wg.Wait()
+	prog.done()
+
+	codes := make([]int, len(usernames))
+	var sections []section
+	for i, username := range usernames {
+		err := errs[i]
+		var events []Event
+		if err == nil {
+			events, err = feedEvents(ctx, feeds[i], opts)
+		}
+		if err != nil {
+			printError(os.Stderr, fmt.Errorf("%s: %w", username, err), opts)
+			codes[i] = exitCode(err)
+			sections = append(sections, section{feed: feed{login: username}, err: err})
+			continue
+		}
+		sections = append(sections, section{feed: feeds[i], events: events})
+	}
+	if err := combine(w, sections, opts); err != nil {
+		printError(os.Stderr, fmt.Errorf("Failed to write %s. Reason: %v", formats[opts.format].label, err), opts)
+		return exitFailure
+	}
+	return reportFailures(usernames, codes, opts)
+}
+
+// getMergedActivity interleaves several users' events into one timeline,
+// newest first, with each line prefixed by who did it. A user whose fetch
+// fails is reported and left out rather than spoiling the whole timeline.
+func getMergedActivity(ctx context.Context, w io.Writer, usernames []string, opts options, prog *progress) int {
+	feeds := make([]feed, len(usernames))
+	errs := make([]error, len(usernames))
+	var wg sync.WaitGroup
+	for i, username := range usernames {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+			feeds[i], errs[i] = fetchFeed(ctx, username, opts, prog)
+		}(i, username)
+	}
+	wg.Wait()
+	prog.done()
+
+	code := exitOK
+	merged := feed{
+		login:     strings.Join(usernames, ","),
+		heading:   strings.Join(usernames, ", "),
+		showActor: true,
+	}
+	if opts.githubActions {
+		fmt.Fprintf(w, "::group::GitHub activity for %s\n", escapeWorkflowData(merged.heading))
+		defer fmt.Fprintln(w, "::endgroup::")
+	}
+	for i, username := range usernames {
+		if errs[i] != nil {
+			printError(w, fmt.Errorf("%s: %w", username, errs[i]), opts)
+			if code == exitOK {
+				code = exitCode(errs[i])
+			}
+			continue
+		}
+		merged.events = append(merged.events, feeds[i].events...)
+		merged.truncated = merged.truncated || feeds[i].truncated
+		merged.parseErrors = append(merged.parseErrors, feeds[i].parseErrors...)
+		merged.raw = append(merged.raw, feeds[i].raw...)
+	}
+	sortTimeline(merged.events)
+	if err := showFeed(ctx, w, merged, opts); err != nil {
+		printError(w, err, opts)
+		return exitCode(err)
 	}
+	return code
+}
+
+// sortTimeline orders events newest first. Events sharing a timestamp are
+// ordered by actor login and then by event ID so the output is stable.
+func sortTimeline(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		if a.Actor.Login != b.Actor.Login {
+			return a.Actor.Login < b.Actor.Login
+		}
+		// IDs are decimal strings, so a shorter one is a smaller number.
+		if len(a.ID) != len(b.ID) {
+			return len(a.ID) < len(b.ID)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// webURL is the root of the web interface that output links point at:
+// --web-url if given, otherwise github.com for the public API and the
+// --base-url without its /api/v3 suffix for Enterprise.
+func webURL(opts options) string {
+	if opts.webURL != "" {
+		return strings.TrimSuffix(opts.webURL, "/")
+	}
+	base := strings.TrimSuffix(opts.baseURL, "/")
+	if u, err := url.Parse(base); err == nil && strings.EqualFold(u.Host, "api.github.com") {
+		return "https://github.com"
+	}
+	return strings.TrimSuffix(base, "/api/v3")
+}
+
+// repoURL is the web page of the repository with the given full name.
+func repoURL(name string, opts options) string {
+	return webURL(opts) + "/" + name
+}
+
+// feedURL returns the events endpoint for username (or --org) and a
+// description of its owner for error messages. Neither name is checked,
+// so each is escaped as one path segment: a "?", "#" or "/" in it must not
+// make a different request from the one --dry-run shows.
+func feedURL(username string, opts options) (apiURL, subject string) {
+	base := strings.TrimSuffix(opts.baseURL, "/")
+	if opts.org != "" {
+		return fmt.Sprintf("%s/orgs/%s/events%s", base, url.PathEscape(opts.org), perPage(opts)), fmt.Sprintf("GitHub organization '%s'", opts.org)
+	}
+	endpoint := "events"
+	if opts.received {
+		endpoint = "received_events"
+	}
+	// /events has private activity only for the token's owner; the
+	// /public variant never has. Use that one when nothing private could
+	// be shown anyway, or when --public-events asks for it. Without a
+	// known owner, /events is left to decide.
+	if opts.publicEvents || (opts.tokenOwner != "" && !strings.EqualFold(username, opts.tokenOwner)) {
+		endpoint += "/public"
+	}
+	return fmt.Sprintf("%s/users/%s/%s%s", base, url.PathEscape(username), endpoint, perPage(opts)), fmt.Sprintf("GitHub user '%s'", username)
+}
+
+// defaultConcurrency is how many users' feeds are fetched at once unless
+// --concurrency says otherwise.
+const defaultConcurrency = 4
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body)
+// GitHub sends defaultPerPage events per page unless asked for more, and
+// at most maxPerPage.
+const (
+	defaultPerPage = 30
+	maxPerPage     = 100
+)
+
+// perPage is the query asking for bigger pages when --limit wants more
+// events than a default page holds, so that fewer requests are needed.
+func perPage(opts options) string {
+	if opts.limit <= defaultPerPage {
+		return ""
+	}
+	return fmt.Sprintf("?per_page=%d", min(opts.limit, maxPerPage))
+}
+
+// fetchFeed fetches the recent events of username, or of --org when set.
+// Progress is reported to prog.
+func fetchFeed(ctx context.Context, username string, opts options, prog *progress) (feed, error) {
+	// Construct the API URL
+	apiURL, subject := feedURL(username, opts)
+	f := feed{login: username, heading: username, showActor: opts.showActor}
+	switch {
+	case opts.org != "":
+		f.login = opts.org
+		f.heading = opts.org
+	case opts.received:
+		f.heading = username + " (received)"
+	}
+
+	// In org and received feeds the actor varies from event to event, so
+	// it's shown there; a user's own feed only shows it when asked.
+	f.showActor = f.showActor || opts.org != "" || opts.received
+
+	var pg page
+	for attempt := 0; ; attempt++ {
+		f.events, f.parseErrors, f.raw, f.truncated = nil, nil, nil, false
+		var err error
+		if pg, err = fetchEvents(ctx, &f, apiURL, subject, 1, opts, prog); err != nil {
+			return feed{}, err
+		}
+
+		// The events API is eventually consistent and sometimes briefly
+		// answers an active user with an empty list, so --retry-empty
+		// asks again before concluding there's no activity.
+		if len(f.events) > 0 || attempt >= opts.retryEmpty {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			// Out of time: the empty answer is the best there is.
+			return f, nil
+		case <-time.After(opts.retryDelay):
+		}
+	}
+
+	for n := 2; pg.next != "" && wantMore(f, opts); n++ {
+		if err := waitForPage(ctx, pg, opts); err != nil {
+			return feed{}, err
+		}
+		var err error
+		if pg, err = fetchEvents(ctx, &f, pg.next, subject, n, opts, prog); err != nil {
+			return feed{}, err
+		}
+	}
+	// The feed is truncated when fetching stopped before its end, but not
+	// at the MaxEvents GitHub keeps: the events past that are gone for
+	// good. --head-only stops at the first page on purpose, so the pages
+	// it left don't count either.
+	fetched := len(f.events) + len(f.parseErrors)
+	f.truncated = f.truncated || !opts.headOnly && pg.next != "" && fetched < activity.MaxEvents && !reachedSince(f.events, opts.since)
+	return f, nil
+}
+
+// wantMore reports whether fetchFeed should fetch the page after those in
+// f. With --limit it pages until it has that many events. With --since it
+// pages until the feed reaches back past it, but no further: the events
+// come newest first, so once a page ends before --since everything after
+// it would be filtered out anyway. --head-only needs only the first page.
+// GitHub only keeps activity.MaxEvents events per feed.
+func wantMore(f feed, opts options) bool {
+	if opts.headOnly {
+		return false
+	}
+	fetched := len(f.events) + len(f.parseErrors)
+	if fetched >= activity.MaxEvents || !opts.since.IsZero() && reachedSince(f.events, opts.since) {
+		return false
+	}
+	if opts.limit > 0 {
+		return len(f.events) < opts.limit
+	}
+	return !opts.since.IsZero()
+}
+
+// fetchEvents fetches page n of a feed from apiURL and adds its events to f.
+func fetchEvents(ctx context.Context, f *feed, apiURL, subject string, n int, opts options, prog *progress) (page, error) {
+	prog.fetchingPage(n)
+	pg, err := opts.source.fetch(ctx, apiURL, subject, opts)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return page{}, withExitCode(exitNetwork, fmt.Errorf("Gave up after the --deadline of %v.", opts.deadline))
+	}
 	if err != nil {
-		fmt.Printf("Error: Failed to read response body. Reason: %v\n", err)
-		return
+		return page{}, err
 	}
 
-	// Unmarshal the JSON data into a slice of Event structs
-	var events []Event
-	if err := json.Unmarshal(body, &events); err != nil {
-		fmt.Printf("Error: Failed to parse the response from the GitHub API. Reason: %v\n", err)
-		return
+	parsed, parseErrors, err := activity.ParseEvents(pg.body)
+	if err != nil {
+		return page{}, fmt.Errorf("Failed to parse the response from the GitHub API. Reason: %v", err)
+	}
+	events := make([]Event, len(parsed))
+	for i := range parsed {
+		events[i].Event = parsed[i]
+	}
+	// Report parse errors by their position in the whole feed, not the page.
+	offset := len(f.events) + len(f.parseErrors)
+	for i := range parseErrors {
+		parseErrors[i].Index += offset
+	}
+	// A page can run past --limit; the rest of it is treated like the
+	// pages that weren't fetched.
+	if opts.limit > 0 && len(f.events)+len(events) > opts.limit {
+		events = events[:opts.limit-len(f.events)]
+		f.truncated = true
+	}
+	f.events = append(f.events, events...)
+	f.parseErrors = append(f.parseErrors, parseErrors...)
+	opts.redact.addEvents(events)
+	if opts.raw {
+		f.raw = append(f.raw, pg.body)
+	}
+	prog.pageFetched(len(events))
+	if opts.onPage != nil {
+		if err := opts.onPage(*f, events); err != nil {
+			return page{}, err
+		}
 	}
+	return pg, nil
+}
 
-	fmt.Printf("Recent Activity for %s:\n\n", username)
+// reachedSince reports whether events, newest first, go back to before
+// since. It is false when since isn't set.
+func reachedSince(events []Event, since time.Time) bool {
+	return !since.IsZero() && len(events) > 0 && events[len(events)-1].CreatedAt.Before(since)
+}
 
-	if len(events) == 0 {
-		fmt.Println("No recent public activity found.")
-		return
+// privateNotice makes sure the private-activity note is printed at most
+// once per run, however many feeds contain private events.
+var privateNotice sync.Once
+
+// notePrivate prints the private-activity note if an authenticated run is
+// about to show events on private repositories.
+func notePrivate(events []Event, opts options) {
+	if opts.token != "" && !opts.quiet && slices.ContainsFunc(events, func(e Event) bool { return !e.Public }) {
+		privateNotice.Do(func() {
+			fmt.Fprintln(os.Stderr, "Note: authenticated — output may include private repository activity (use --public-only to hide it).")
+		})
+	}
+}
+
+// showFeed filters the feed's events and writes them to w in the chosen format.
+func showFeed(ctx context.Context, w io.Writer, f feed, opts options) error {
+	if opts.raw {
+		for _, body := range f.raw {
+			if _, err := fmt.Fprintln(w, opts.redact.redact(string(body))); err != nil {
+				return fmt.Errorf("Failed to write the raw response. Reason: %v", err)
+			}
+		}
+		return nil
+	}
+	events, err := feedEvents(ctx, f, opts)
+	if err != nil {
+		return err
+	}
+	if opts.chart {
+		counts := make(map[string]int)
+		for _, row := range countBy(events, cmp.Or(opts.countBy, "type"), location(opts)) {
+			counts[row.Key] = row.Count
+		}
+		// Bars fill the terminal, or a fixed width when piped.
+		width := defaultWrapWidth
+		if opts.output == "" && isTerminal(os.Stdout) {
+			width = terminalWidth(os.Stdout)
+		}
+		if _, err := io.WriteString(w, renderBarChart(counts, width)); err != nil {
+			return fmt.Errorf("Failed to write the chart. Reason: %v", err)
+		}
+		return nil
+	}
+	if opts.countBy != "" {
+		rows := countBy(events, opts.countBy, location(opts))
+		if err := printTable(w, opts.countBy, rows, opts); err != nil {
+			return fmt.Errorf("Failed to write the table. Reason: %v", err)
+		}
+		return nil
+	}
+	if opts.reposSummary {
+		if err := printReposSummary(w, events, opts); err != nil {
+			return fmt.Errorf("Failed to write the summary. Reason: %v", err)
+		}
+		return nil
 	}
+	if opts.listRepos {
+		for _, repo := range touchedRepos(events) {
+			if _, err := fmt.Fprintln(w, repo); err != nil {
+				return fmt.Errorf("Failed to write the repositories. Reason: %v", err)
+			}
+		}
+		return nil
+	}
+	format := formats[opts.format]
+	if err := format.new(f, opts).Render(w, events); err != nil {
+		return fmt.Errorf("Failed to write %s. Reason: %v", format.label, err)
+	}
+	return nil
+}
+
+// feedEvents returns the events of f that are to be shown: those that
+// pass the filters, with the lookups asked for done.
+func feedEvents(ctx context.Context, f feed, opts options) ([]Event, error) {
+	// Filter once up front: --sample-rate without --seed would keep a
+	// different subset on every call.
+	opts.queried = strings.Split(f.login, ",")
+	events := filterEvents(f.events, opts)
+	notePrivate(events, opts)
+	if opts.onlyNew {
+		var err error
+		if events, err = onlyNew(events); err != nil {
+			return nil, err
+		}
+	}
+	if opts.headOnly && len(events) > 1 {
+		events = events[:1]
+	}
+	if opts.enrichCommits {
+		enrichCommits(ctx, events, opts)
+	}
+	if opts.resolveState {
+		resolveState(ctx, events, opts)
+	}
+	if opts.strict {
+		if unknown := unknownTypes(events); len(unknown) > 0 {
+			return nil, withExitCode(exitUnknownType, fmt.Errorf("Unknown event type(s) found with --strict: %s", strings.Join(unknown, ", ")))
+		}
+	}
+	return events, nil
+}
+
+// printEvents writes one "- ..." line per event.
+func printEvents(w io.Writer, events []Event, showActor bool, opts options) {
+	// Wrapping is only for people reading a terminal; anything reading a
+	// pipe expects one event per line. w may be a buffer on its way to
+	// stdout, so it's stdout that's checked.
+	width := 0
+	if opts.wrap && opts.output == "" && isTerminal(os.Stdout) {
+		width = terminalWidth(os.Stdout)
+	}
+	// Colors, likewise, only make sense on a terminal.
+	color := opts.theme != nil && opts.output == "" && isTerminal(os.Stdout)
 
-	// Process and display each event
 	for _, event := range events {
-		switch event.Type {
-		case "PushEvent":
-			fmt.Printf("- Pushed %d commit(s) to %s\n", len(event.Payload.Commits), event.Repo.Name)
-		case "CreateEvent":
-			fmt.Printf("- Created a new %s in %s\n", event.Payload.RefType, event.Repo.Name)
-		case "IssuesEvent":
-			fmt.Printf("- %s an issue in %s: \"%s\"\n", strings.Title(event.Payload.Action), event.Repo.Name, event.Payload.Issue.Title)
-		case "IssueCommentEvent":
-			fmt.Printf("- Commented on an issue in %s: \"%s\"\n", event.Repo.Name, event.Payload.Issue.Title)
-		case "WatchEvent":
-			fmt.Printf("- %s watching %s\n", strings.Title(event.Payload.Action), event.Repo.Name)
-		case "ForkEvent":
-			fmt.Printf("- Forked %s to %s\n", event.Repo.Name, event.Payload.Forkee.FullName)
-		case "PullRequestEvent":
-			fmt.Printf("- %s a pull request in %s: \"%s\"\n", strings.Title(event.Payload.Action), event.Repo.Name, event.Payload.PullRequest.Title)
-		case "PublicEvent":
-			fmt.Printf("- Made %s public\n", event.Repo.Name)
+		line := formatEvent(event, opts)
+		if showActor && event.Actor.Login != "" {
+			line = event.Actor.Login + " " + lowerFirst(line)
+		}
+		indent := 2
+		switch {
+		case opts.compactTime:
+			stamp := fmt.Sprintf("%-10s ", compactTime(event.CreatedAt, now(), location(opts)))
+			line = stamp + line
+			indent += len(stamp)
+		case event.CreatedAt.IsZero():
+			// GitHub didn't say when; there's nothing to show.
+		case opts.absolute:
+			line += " (" + event.CreatedAt.In(location(opts)).Format(time.RFC3339) + ")"
 		default:
-			fmt.Printf("- Performed a %s on %s\n", event.Type, event.Repo.Name)
+			line += " (" + relativeTime(event.CreatedAt, now()) + ")"
+		}
+		line = "- " + line
+		if width > 0 {
+			line = wrapLine(line, width, indent)
+		}
+		if color {
+			line = colorize(line, event.Type, opts.theme)
+		}
+		fmt.Fprintln(w, line)
+
+		if opts.verbose || opts.enrichCommits {
+			printCommits(w, event, opts)
+		}
+	}
+}
+
+// location is the time zone timestamps are shown in.
+func location(opts options) *time.Location {
+	if opts.timezone != nil {
+		return opts.timezone
+	}
+	return time.Local
+}
+
+// displayRepo is activity.RepoName, shortened by --short-repo to drop the owner
+// when it's the queried user. Repos of anyone else keep the full name so
+// it stays clear whose they are.
+func displayRepo(repo Repo, opts options) string {
+	name := activity.RepoName(repo)
+	if !opts.shortRepo {
+		return name
+	}
+	if _, short, ok := strings.Cut(repo.Name, "/"); ok && ownedByQueried(repo, opts) {
+		return short
+	}
+	return name
+}
+
+// ownedByQueried reports whether repo belongs to one of the queried users,
+// going by the owner part of its name. GitHub logins are case-insensitive.
+func ownedByQueried(repo Repo, opts options) bool {
+	owner, _, ok := strings.Cut(repo.Name, "/")
+	return ok && slices.ContainsFunc(opts.queried, func(login string) bool { return strings.EqualFold(login, owner) })
+}
+
+// relativeTime puts how long ago t was in the largest whole unit, e.g.
+// "2 hours ago" or "3 days ago". Times in the future, which only a clock
+// out of step with GitHub's produces, count as just now.
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return ago(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return ago(int(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return ago(int(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return ago(int(d/(30*24*time.Hour)), "month")
+	}
+	return ago(int(d/(365*24*time.Hour)), "year")
+}
+
+// ago is "1 day ago" or "n days ago".
+func ago(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit + " ago"
+	}
+	return fmt.Sprintf("%d %ss ago", n, unit)
+}
+
+// compactTime is the --compact-time timestamp of an event: just the time
+// of day for events from today, the date for anything older. "Today" is
+// the calendar day in loc, not the last 24 hours.
+func compactTime(t, now time.Time, loc *time.Location) string {
+	t, now = t.In(loc), now.In(loc)
+	if t.Year() == now.Year() && t.YearDay() == now.YearDay() {
+		return t.Format("15:04")
+	}
+	return t.Format("2006-01-02")
+}
+
+// printCommits writes one indented line per commit of a push: its SHA,
+// with --verbose the first line of its message and its author, and with
+// --enrich-commits its line counts, e.g.
+//
+//	abc1234 Fix bug (by Jane Doe) +12/-3
+func printCommits(w io.Writer, event Event, opts options) {
+	for _, commit := range event.Payload.Commits {
+		stats, enriched := event.CommitStats[commit.SHA]
+		if !opts.verbose && !enriched {
+			continue
+		}
+		line := "  " + activity.ShortSHA(commit.SHA)
+		if opts.verbose {
+			message, _, _ := strings.Cut(commit.Message, "\n")
+			line += " " + message
+			if name := commit.Author.Name; name != "" && !(opts.hideSelfAuthor && isPusher(commit.Author, event.Actor.Login)) {
+				line += " (by " + name + ")"
+			}
+		}
+		if enriched {
+			line += fmt.Sprintf(" +%d/-%d", stats.Additions, stats.Deletions)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// isPusher reports whether a commit author looks like the GitHub user
+// login. Git authors carry a name and email rather than a login, so this
+// matches the name or the local part of a GitHub noreply address such as
+// 12345+octocat@users.noreply.github.com.
+func isPusher(author CommitAuthor, login string) bool {
+	if login == "" {
+		return false
+	}
+	if strings.EqualFold(author.Name, login) {
+		return true
+	}
+	local, domain, ok := strings.Cut(author.Email, "@")
+	if !ok || !strings.EqualFold(domain, "users.noreply.github.com") {
+		return false
+	}
+	if _, name, ok := strings.Cut(local, "+"); ok {
+		local = name
+	}
+	return strings.EqualFold(local, login)
+}
+
+// lowerFirst lowercases the first letter of s so that a sentence can be
+// continued after a prefix such as the actor's login.
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// filterEvents returns the events that pass the filters set in opts.
+// The --type allow-list is applied first, then --hide-type removes
+// anything it names, so a type given to both ends up hidden.
+func filterEvents(events []Event, opts options) []Event {
+	events = sampleEvents(events, opts)
+	if opts.types == "" && opts.hideTypes == "" && opts.onlyAction == "" && opts.repos == "" && opts.orgFilter == "" && opts.hideBots == nil && !opts.publicOnly && !opts.onlyOwned && opts.since.IsZero() {
+		return events
+	}
+	allowed := splitList(opts.types)
+	hidden := splitList(opts.hideTypes)
+	repos := make(map[string]bool)
+	for repo := range splitList(opts.repos) {
+		repos[normalizeRepo(repo)] = true
+	}
+	orgs := make(map[string]bool)
+	for org := range splitList(opts.orgFilter) {
+		orgs[strings.ToLower(org)] = true
+	}
+	actions := make(map[string]bool)
+	for action := range splitList(opts.onlyAction) {
+		actions[strings.ToLower(action)] = true
+	}
+
+	var kept []Event
+	for _, event := range events {
+		if len(allowed) > 0 && !allowed[event.Type] {
+			continue
+		}
+		if hidden[event.Type] {
+			continue
+		}
+		// Events without an action, such as pushes, never match.
+		if len(actions) > 0 && !actions[strings.ToLower(event.Payload.Action)] {
+			continue
+		}
+		if len(repos) > 0 && !repos[normalizeRepo(event.Repo.Name)] {
+			continue
+		}
+		// Events on personal repositories have no org and never match.
+		if len(orgs) > 0 && !orgs[strings.ToLower(event.Org.Login)] {
+			continue
+		}
+		if opts.onlyOwned && !ownedByQueried(event.Repo, opts) {
+			continue
+		}
+		if opts.hideBots != nil && opts.hideBots.MatchString(event.Actor.Login) {
+			continue
+		}
+		if opts.publicOnly && !event.Public {
+			continue
+		}
+		if !opts.since.IsZero() && event.CreatedAt.Before(opts.since) {
+			continue
 		}
+		kept = append(kept, event)
+	}
+	return kept
+}
+
+// defaultBotPattern matches the logins GitHub gives app accounts, such as
+// dependabot[bot] and github-actions[bot].
+var defaultBotPattern = regexp.MustCompile(`\[bot\]$`)
+
+// normalizeRepo returns the form of a repository name used for matching.
+// GitHub treats "Owner/Repo" and "owner/repo" as the same repository but
+// the API preserves the case it was created with.
+func normalizeRepo(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// sampleEvents keeps each event with probability --sample-rate. With a
+// fixed --seed the same events are kept on every run.
+func sampleEvents(events []Event, opts options) []Event {
+	if opts.sampleRate >= 1 {
+		return events
+	}
+	seed := opts.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	var kept []Event
+	for _, event := range events {
+		if rng.Float64() < opts.sampleRate {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
+
+// splitList turns a comma-separated flag value into a set, ignoring
+// surrounding whitespace and empty items.
+func splitList(value string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+// page is one response from a paginated GitHub endpoint.
+type page struct {
+	body []byte
+	next string // URL of the following page, empty on the last one
+
+	// remaining and reset are the X-RateLimit-Remaining and -Reset
+	// headers. reset is zero when the response didn't say.
+	remaining int
+	reset     time.Time
+}
+
+// fetchPage requests a single page of events and returns the raw body.
+// subject names the feed's owner in error messages.
+func fetchPage(ctx context.Context, apiURL, subject string, opts options) (page, error) {
+	req, err := newRequest(ctx, apiURL, opts)
+	if err != nil {
+		return page{}, fmt.Errorf("Could not build the request. Reason: %w", err)
+	}
+	// Ask for the page only if it changed since the cached copy.
+	cached, ok := cachedPage(apiURL, opts)
+	if ok {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	// Make the HTTP GET request, never reading more than --max-body-size
+	pg, err := apiClient(opts).Do(req)
+	var apiErr *activity.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return page{}, statusError(apiErr, subject, opts)
+	case errors.Is(err, activity.ErrTooLarge):
+		return page{}, fmt.Errorf("The response exceeded max body size (%d bytes).", opts.maxBodySize)
+	case err != nil:
+		return page{}, withExitCode(exitNetwork, fmt.Errorf("Could not reach GitHub API. Reason: %w", err))
+	}
+	if pg.NotModified {
+		return withRateLimit(page{body: cached.Body, next: cached.Next}, pg.Header), nil
+	}
+
+	// The secondary limits are sometimes reported with a 200 and an error
+	// object where the list of events should be.
+	if bytes.HasPrefix(bytes.TrimSpace(pg.Body), []byte("{")) {
+		if message := apiErrorMessage(bytes.NewReader(pg.Body)); isSecondaryLimit(message) {
+			err := fmt.Errorf("API error (%d): %s", http.StatusOK, message)
+			return page{}, withExitCode(exitRateLimited, &secondaryLimitError{err: err, retryAfter: retryAfter(pg.Header)})
+		}
+	}
+	result := withRateLimit(page{body: pg.Body, next: pg.Next}, pg.Header)
+	storePage(apiURL, pg.Header.Get("ETag"), result, opts)
+	return result, nil
+}
+
+// statusError turns a response other than 200 OK into the error to show,
+// with a hint where the remedy isn't obvious. subject names the feed's
+// owner.
+func statusError(apiErr *activity.APIError, subject string, opts options) error {
+	status := apiErr.StatusCode
+	if status >= 300 && status < 400 {
+		return fmt.Errorf("GitHub API redirected to %s, which wasn't followed because of --max-redirects.", apiErr.Header.Get("Location"))
+	}
+	if status == 404 {
+		return withExitCode(exitNotFound, fmt.Errorf("Could not find %s.", subject))
+	}
+	if status == 410 {
+		return withExitCode(exitNotFound, fmt.Errorf("Gone (410): %s no longer exists.", subject))
+	}
+	// GitHub uses 451 for content taken down, e.g. after a DMCA notice.
+	if status == 451 {
+		return fmt.Errorf("Unavailable for legal reasons (451): access to %s has been restricted.", subject)
+	}
+	// A 401 means the token itself was refused, unlike a 403, which points
+	// at rate limiting or permissions, so the remedy is different.
+	if status == 401 && opts.token == "" {
+		return fmt.Errorf("Authentication required (401): %s needs a token.\n"+
+			"Hint: set GITHUB_TOKEN or pass --token.", subject)
+	}
+	if status == 401 {
+		return fmt.Errorf("Authentication failed (401) — check the token from %s.\n"+
+			"Hint: the token may be expired or revoked, or may not have the scopes this request needs.", opts.tokenFrom)
+	}
+	err := fmt.Errorf("Received status code %d from GitHub API.", status)
+	if apiErr.Message != "" {
+		err = fmt.Errorf("API error (%d): %s", status, apiErr.Message)
+	}
+	if isSecondaryLimit(apiErr.Message) {
+		return withExitCode(exitRateLimited, &secondaryLimitError{err: err, retryAfter: retryAfter(apiErr.Header)})
+	}
+	if isRateLimited(apiErr) {
+		if opts.token == "" {
+			err = fmt.Errorf("%w\nHint: anonymous requests are limited to 60 an hour; set GITHUB_TOKEN or pass --token for 5,000.", err)
+		}
+		return withExitCode(exitRateLimited, err)
+	}
+	if status == http.StatusForbidden {
+		return fmt.Errorf("%w\nHint: %s", err, forbiddenHint(opts))
+	}
+	return err
+}
+
+// isRateLimited reports whether a failed response was GitHub refusing the
+// request for rate-limit reasons rather than, say, missing permissions.
+func isRateLimited(apiErr *activity.APIError) bool {
+	if apiErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if apiErr.StatusCode != http.StatusForbidden {
+		return false
+	}
+	return apiErr.Header.Get("X-RateLimit-Remaining") == "0" || strings.Contains(strings.ToLower(apiErr.Message), "rate limit")
+}
+
+// forbiddenHint suggests what to do about a 403 that isn't rate limiting,
+// which depends on whether a token was sent.
+func forbiddenHint(opts options) string {
+	if opts.token == "" {
+		return "this may need a token; set GITHUB_TOKEN or pass --token."
+	}
+	return fmt.Sprintf("the token from %s may lack the permissions this needs, or an organization may require it to be authorized for SSO.", opts.tokenFrom)
+}
+
+// apiErrorMessage returns GitHub's own explanation from an error response
+// body such as {"message": "API rate limit exceeded"}, or "" if there is none.
+func apiErrorMessage(body io.Reader) string {
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(body, 64<<10)).Decode(&apiErr); err != nil {
+		return ""
 	}
+	return apiErr.Message
 }
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+// eventIDs lists the IDs of events, to compare what a filter kept.
+func eventIDs(events []Event) []string {
+	ids := []string{}
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestFilterEventsTypeAndHideType(t *testing.T) {
+	events := []Event{
+		{Event: activity.Event{ID: "1", Type: "PushEvent"}},
+		{Event: activity.Event{ID: "2", Type: "WatchEvent"}},
+		{Event: activity.Event{ID: "3", Type: "IssuesEvent"}},
+		{Event: activity.Event{ID: "4", Type: "PushEvent"}},
+	}
+	tests := []struct {
+		name      string
+		types     string
+		hideTypes string
+		want      []string
+	}{
+		{"neither", "", "", []string{"1", "2", "3", "4"}},
+		{"allow-list only", "PushEvent,IssuesEvent", "", []string{"1", "3", "4"}},
+		{"hidden only", "", "WatchEvent", []string{"1", "3", "4"}},
+		{"hidden after the allow-list", "PushEvent,IssuesEvent", "PushEvent", []string{"3"}},
+		{"hiding a type the allow-list dropped", "PushEvent", "WatchEvent", []string{"1", "4"}},
+		{"hiding everything allowed", "WatchEvent", "WatchEvent", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options{sampleRate: 1, types: tt.types, hideTypes: tt.hideTypes}
+			if got := eventIDs(filterEvents(events, opts)); !slices.Equal(got, tt.want) {
+				t.Errorf("filterEvents(--type %q, --hide-type %q) kept %v, want %v", tt.types, tt.hideTypes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWithDeadlineGivesUpOnSlowServer(t *testing.T) {
+	// The server never answers; only the deadline can end the run.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	opts := options{
+		baseURL:     srv.URL,
+		source:      apiSource{},
+		format:      "text",
+		output:      out,
+		concurrency: 1,
+		maxBodySize: 1 << 20,
+		sampleRate:  1,
+		noCache:     true,
+		deadline:    200 * time.Millisecond,
+	}
+	start := time.Now()
+	code := runWithDeadline([]string{"alice"}, opts)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("the run took %v despite a deadline of %v", elapsed, opts.deadline)
+	}
+	if code != exitNetwork {
+		t.Errorf("exit code = %d, want %d (network)", code, exitNetwork)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(written), "Gave up after the --deadline of 200ms.") {
+		t.Errorf("output = %q, want the deadline error", written)
+	}
+}
+
+func TestSampleEventsIsReproducibleWithSeed(t *testing.T) {
+	var events []Event
+	for i := range 100 {
+		events = append(events, Event{Event: activity.Event{ID: strconv.Itoa(i)}})
+	}
+
+	opts := options{sampleRate: 0.3, seed: 42}
+	first := eventIDs(sampleEvents(events, opts))
+	if again := eventIDs(sampleEvents(events, opts)); !slices.Equal(first, again) {
+		t.Errorf("the same seed kept %v, then %v", first, again)
+	}
+	if n := len(first); n == 0 || n == len(events) {
+		t.Errorf("--sample-rate 0.3 kept %d of %d events", n, len(events))
+	}
+	opts.seed = 43
+	if other := eventIDs(sampleEvents(events, opts)); slices.Equal(first, other) {
+		t.Errorf("seeds 42 and 43 kept the same events %v", first)
+	}
+
+	if kept := sampleEvents(events, options{sampleRate: 1}); len(kept) != len(events) {
+		t.Errorf("--sample-rate 1 kept %d of %d events", len(kept), len(events))
+	}
+}
+
+func TestFetchPageExplains401(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenFrom string
+		want      []string
+	}{
+		{"without a token", "", "", []string{"Authentication required (401)", "GitHub user 'alice' needs a token", "set GITHUB_TOKEN or pass --token"}},
+		{"with a refused token", "expired", "GITHUB_TOKEN", []string{"Authentication failed (401)", "check the token from GITHUB_TOKEN", "expired or revoked"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options{token: tt.token, tokenFrom: tt.tokenFrom, maxBodySize: 1 << 20, noCache: true}
+			_, err := fetchPage(context.Background(), srv.URL+"/users/alice/events", "GitHub user 'alice'", opts)
+			if err == nil {
+				t.Fatal("fetchPage succeeded on a 401")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q lacks %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentUsersWriteWholeSectionsInOrder(t *testing.T) {
+	// Later users answer sooner, so the sections finish in reverse order.
+	users := []string{"ann", "bob", "cat", "dan"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		login := userInPath(r.URL.Path)
+		i := slices.Index(users, login)
+		time.Sleep(time.Duration(len(users)-i) * 20 * time.Millisecond)
+		fmt.Fprintf(w, `[
+			{"id":"%[2]d1","type":"WatchEvent","repo":{"name":"%[1]s/one"},"payload":{"action":"started"},"created_at":"2024-05-01T10:00:00Z"},
+			{"id":"%[2]d2","type":"WatchEvent","repo":{"name":"%[1]s/two"},"payload":{"action":"started"},"created_at":"2024-05-01T09:00:00Z"}
+		]`, login, i)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	opts := options{
+		baseURL:     srv.URL,
+		source:      apiSource{},
+		format:      "text",
+		output:      out,
+		concurrency: len(users),
+		maxBodySize: 1 << 20,
+		sampleRate:  1,
+		noCache:     true,
+		absolute:    true,
+		timezone:    time.UTC,
+		// A theme exercises the colored path; the file output turns the
+		// colors off, but the sections are still rendered concurrently.
+		theme: map[string]string{"WatchEvent": "yellow"},
+	}
+	if code := runWithDeadline(users, opts); code != exitOK {
+		t.Fatalf("exit code = %d", code)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want strings.Builder
+	for i, login := range users {
+		if i > 0 {
+			want.WriteString("\n")
+		}
+		fmt.Fprintf(&want, "Recent Activity for %[1]s:\n\n- Started watching %[1]s/one (2024-05-01T10:00:00Z)\n- Started watching %[1]s/two (2024-05-01T09:00:00Z)\n", login)
+	}
+	if string(written) != want.String() {
+		t.Errorf("output:\n%s\nwant:\n%s", written, want.String())
+	}
+}
+
+func TestNormalizeRepo(t *testing.T) {
+	tests := map[string]string{
+		"octocat/Hello-World":   "octocat/hello-world",
+		"OctoCat/HELLO-WORLD":   "octocat/hello-world",
+		" octocat/hello-world ": "octocat/hello-world",
+		"":                      "",
+	}
+	for name, want := range tests {
+		if got := normalizeRepo(name); got != want {
+			t.Errorf("normalizeRepo(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFilterEventsRepoIgnoresCase(t *testing.T) {
+	events := []Event{
+		{Event: activity.Event{ID: "1", Repo: activity.Repo{Name: "Octocat/Hello-World"}}},
+		{Event: activity.Event{ID: "2", Repo: activity.Repo{Name: "octocat/hello-world"}}},
+		{Event: activity.Event{ID: "3", Repo: activity.Repo{Name: "octocat/Spoon-Knife"}}},
+		{Event: activity.Event{ID: "4"}}, // a deleted repository
+	}
+	tests := []struct {
+		repos string
+		want  []string
+	}{
+		{"octocat/hello-world", []string{"1", "2"}},
+		{"OCTOCAT/HELLO-WORLD", []string{"1", "2"}},
+		{"octocat/hello-world, OctoCat/spoon-knife", []string{"1", "2", "3"}},
+		{"octocat/hello", []string{}},
+	}
+	for _, tt := range tests {
+		opts := options{sampleRate: 1, repos: tt.repos}
+		if got := eventIDs(filterEvents(events, opts)); !slices.Equal(got, tt.want) {
+			t.Errorf("--repo %q kept %v, want %v", tt.repos, got, tt.want)
+		}
+	}
+}
+
+func TestFetchPageExplains410And451(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/status/"))
+		w.WriteHeader(status)
+		w.Write([]byte(`{"message":"Repository access blocked"}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		status int
+		want   string
+		code   int
+	}{
+		{http.StatusGone, "Gone (410): GitHub user 'alice' no longer exists.", exitNotFound},
+		{http.StatusUnavailableForLegalReasons, "Unavailable for legal reasons (451): access to GitHub user 'alice' has been restricted.", exitFailure},
+	}
+	for _, tt := range tests {
+		opts := options{maxBodySize: 1 << 20, noCache: true}
+		_, err := fetchPage(context.Background(), fmt.Sprintf("%s/status/%d", srv.URL, tt.status), "GitHub user 'alice'", opts)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("status %d: error = %v, want %q", tt.status, err, tt.want)
+		}
+		if code := exitCode(err); code != tt.code {
+			t.Errorf("status %d: exit code = %d, want %d", tt.status, code, tt.code)
+		}
+	}
+}
+
+func TestWebURLForEnterprise(t *testing.T) {
+	tests := []struct {
+		baseURL, webURL string
+		want            string
+	}{
+		{"https://api.github.com", "", "https://github.com"},
+		{"https://API.GitHub.com/", "", "https://github.com"},
+		{"https://github.example.com/api/v3", "", "https://github.example.com"},
+		{"https://github.example.com/api/v3/", "", "https://github.example.com"},
+		{"https://api.example.com/api/v3", "https://code.example.com/", "https://code.example.com"},
+	}
+	for _, tt := range tests {
+		opts := options{baseURL: tt.baseURL, webURL: tt.webURL}
+		if got := webURL(opts); got != tt.want {
+			t.Errorf("webURL(--base-url %q, --web-url %q) = %q, want %q", tt.baseURL, tt.webURL, got, tt.want)
+		}
+		if got, want := repoURL("o/r", opts), tt.want+"/o/r"; got != want {
+			t.Errorf("repoURL with --base-url %q = %q, want %q", tt.baseURL, got, want)
+		}
+	}
+}
+
+func TestCompactTimeAroundMidnight(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 00:30 on 2 May in Berlin is still 1 May in UTC.
+	now := time.Date(2024, 5, 1, 22, 30, 0, 0, time.UTC)
+	tests := []struct {
+		event time.Time
+		loc   *time.Location
+		want  string
+	}{
+		{time.Date(2024, 5, 1, 22, 5, 0, 0, time.UTC), berlin, "00:05"},
+		{time.Date(2024, 5, 1, 21, 55, 0, 0, time.UTC), berlin, "2024-05-01"},
+		{time.Date(2024, 5, 1, 21, 55, 0, 0, time.UTC), time.UTC, "21:55"},
+		{time.Date(2024, 4, 30, 23, 59, 0, 0, time.UTC), time.UTC, "2024-04-30"},
+		{time.Date(2023, 5, 1, 22, 0, 0, 0, time.UTC), time.UTC, "2023-05-01"}, // same day of the year, a year earlier
+	}
+	for _, tt := range tests {
+		if got := compactTime(tt.event, now, tt.loc); got != tt.want {
+			t.Errorf("compactTime(%v) in %s = %q, want %q", tt.event, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestFilterEventsOnlyOwned(t *testing.T) {
+	events := []Event{
+		{Event: activity.Event{ID: "1", Repo: activity.Repo{Name: "alice/dotfiles"}}},
+		{Event: activity.Event{ID: "2", Repo: activity.Repo{Name: "golang/go"}}},
+		{Event: activity.Event{ID: "3", Repo: activity.Repo{Name: "Alice/blog"}}},
+		{Event: activity.Event{ID: "4", Repo: activity.Repo{Name: "bob/alice"}}},
+		{Event: activity.Event{ID: "5", Repo: activity.Repo{Name: "bob/notes"}}},
+		{Event: activity.Event{ID: "6"}}, // a deleted repository has no owner
+	}
+	tests := []struct {
+		queried []string
+		want    []string
+	}{
+		{[]string{"alice"}, []string{"1", "3"}},
+		{[]string{"alice", "bob"}, []string{"1", "3", "4", "5"}},
+		{[]string{"carol"}, nil},
+	}
+	for _, tt := range tests {
+		opts := options{sampleRate: 1, onlyOwned: true, queried: tt.queried}
+		if got := eventIDs(filterEvents(events, opts)); !slices.Equal(got, tt.want) {
+			t.Errorf("--only-owned for %v kept %v, want %v", tt.queried, got, tt.want)
+		}
+	}
+}
+
+func TestFilterEventsOnlyAction(t *testing.T) {
+	events := []Event{
+		{Event: activity.Event{ID: "1", Type: "IssuesEvent", Payload: activity.Payload{Action: "opened"}}},
+		{Event: activity.Event{ID: "2", Type: "PushEvent"}}, // pushes have no action
+		{Event: activity.Event{ID: "3", Type: "PullRequestEvent", Payload: activity.Payload{Action: "closed"}}},
+		{Event: activity.Event{ID: "4", Type: "IssuesEvent", Payload: activity.Payload{Action: "reopened"}}},
+		{Event: activity.Event{ID: "5", Type: "CreateEvent"}},
+	}
+	tests := []struct {
+		onlyAction string
+		want       []string
+	}{
+		{"opened", []string{"1"}},
+		{"Opened, REOPENED", []string{"1", "4"}},
+		{"closed,merged", []string{"3"}},
+		{"pushed", nil},
+	}
+	for _, tt := range tests {
+		opts := options{sampleRate: 1, onlyAction: tt.onlyAction}
+		if got := eventIDs(filterEvents(events, opts)); !slices.Equal(got, tt.want) {
+			t.Errorf("--only-action %q kept %v, want %v", tt.onlyAction, got, tt.want)
+		}
+	}
+	if got := eventIDs(filterEvents(events, options{sampleRate: 1})); len(got) != len(events) {
+		t.Errorf("without --only-action kept %v, want every event", got)
+	}
+}
+
+func TestFetchFeedFollowsLinkHeader(t *testing.T) {
+	// Three pages of 100 events, linked the way GitHub links them.
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		n, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		n = max(n, 1)
+		if n < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/users/alice/events?page=%d&per_page=100>; rel="next", <http://%s/users/alice/events?page=3&per_page=100>; rel="last"`, r.Host, n+1, r.Host))
+		}
+		var events []string
+		for i := range 100 {
+			events = append(events, fmt.Sprintf(`{"id":"%d","type":"WatchEvent"}`, 1000-100*(n-1)-i))
+		}
+		w.Write([]byte("[" + strings.Join(events, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		limit         int
+		wantRequests  []string
+		wantEvents    int
+		wantTruncated bool
+	}{
+		{0, []string{"/users/alice/events"}, 100, true},
+		{30, []string{"/users/alice/events"}, 30, true},
+		{150, []string{"/users/alice/events?per_page=100", "/users/alice/events?page=2&per_page=100"}, 150, true},
+		{300, []string{"/users/alice/events?per_page=100", "/users/alice/events?page=2&per_page=100", "/users/alice/events?page=3&per_page=100"}, 300, false},
+	}
+	for _, tt := range tests {
+		requested = nil
+		opts := options{baseURL: srv.URL, limit: tt.limit, maxBodySize: 1 << 20, noCache: true, source: apiSource{}}
+		f, err := fetchFeed(context.Background(), "alice", opts, newProgress(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(requested, tt.wantRequests) {
+			t.Errorf("--limit %d requested %q, want %q", tt.limit, requested, tt.wantRequests)
+		}
+		if len(f.events) != tt.wantEvents || f.truncated != tt.wantTruncated {
+			t.Errorf("--limit %d got %d events (truncated %v), want %d (truncated %v)", tt.limit, len(f.events), f.truncated, tt.wantEvents, tt.wantTruncated)
+		}
+		if len(f.events) > 0 && f.events[len(f.events)-1].ID != strconv.Itoa(1001-len(f.events)) {
+			t.Errorf("--limit %d ended on event %s, want the events in feed order", tt.limit, f.events[len(f.events)-1].ID)
+		}
+	}
+}
+
+func TestStatsLine(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"1","type":"WatchEvent"},{"id":"2","type":"WatchEvent"}]`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		statsLine bool
+		debug     bool
+		want      bool
+	}{
+		{"neither", false, false, false},
+		{"--stats-line", true, false, true},
+		{"--debug", false, true, true},
+	}
+	for _, tt := range tests {
+		opts := options{
+			baseURL:     srv.URL,
+			source:      apiSource{},
+			format:      "text",
+			output:      filepath.Join(t.TempDir(), "out.txt"),
+			statsLine:   tt.statsLine,
+			debug:       tt.debug,
+			concurrency: 1,
+			maxBodySize: 1 << 20,
+			sampleRate:  1,
+			noCache:     true,
+		}
+		_, stderr := captureOutput(t, func() {
+			if code := runWithDeadline([]string{"alice"}, opts); code != exitOK {
+				t.Errorf("%s: exit code = %d", tt.name, code)
+			}
+		})
+		if got := strings.Contains(stderr, "Fetched 2 events across 1 pages in "); got != tt.want {
+			t.Errorf("%s: stats line printed = %v, want %v; stderr:\n%s", tt.name, got, tt.want, stderr)
+		}
+	}
+}
+
+func TestFeedURLEscapesNames(t *testing.T) {
+	tests := []struct {
+		username string
+		opts     options
+		want     string
+	}{
+		{"octocat", options{}, "https://api.github.com/users/octocat/events"},
+		{"a?b#c/d", options{}, "https://api.github.com/users/a%3Fb%23c%2Fd/events"},
+		{"../orgs/x", options{publicEvents: true}, "https://api.github.com/users/..%2Forgs%2Fx/events/public"},
+		{"", options{org: "my org?"}, "https://api.github.com/orgs/my%20org%3F/events"},
+	}
+	for _, tt := range tests {
+		tt.opts.baseURL = activity.DefaultBaseURL
+		if got, _ := feedURL(tt.username, tt.opts); got != tt.want {
+			t.Errorf("feedURL(%q) = %s, want %s", cmp.Or(tt.username, tt.opts.org), got, tt.want)
+		}
+	}
+}
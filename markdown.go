@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// markdownEscaper backslash-escapes the characters that would otherwise
+// turn parts of an issue title into markup.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`,
+	"[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "#", `\#`,
+)
+
+// mentionPattern matches an @mention that GitHub would turn into a
+// notification: an @ not preceded by a word character (so email addresses
+// are left alone) followed by a login.
+var mentionPattern = regexp.MustCompile("(^|[^\\w`])@([A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)")
+
+// writeMarkdown renders events as a Markdown list with repository links,
+// for pasting into a README or issue. Unless --no-mention-escape is given,
+// @mentions are wrapped in backticks so that publishing the list doesn't
+// notify everyone mentioned in a title.
+func writeMarkdown(w io.Writer, heading string, events []Event, showActor bool, opts options) error {
+	fmt.Fprintf(w, "## Recent Activity for %s\n\n", markdownEscaper.Replace(heading))
+	for _, event := range events {
+		line := formatEvent(event, opts)
+		if showActor && event.Actor.Login != "" {
+			line = event.Actor.Login + " " + lowerFirst(line)
+		}
+
+		repo := displayRepo(event.Repo, opts)
+		before, after, ok := strings.Cut(line, repo)
+		if ok && event.Repo.Name != "" {
+			line = markdownText(before, opts) + fmt.Sprintf("[%s](%s)", markdownEscaper.Replace(repo), repoURL(event.Repo.Name, opts)) + markdownText(after, opts)
+		} else {
+			line = markdownText(line, opts)
+		}
+		if _, err := fmt.Fprintf(w, "- %s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markdownText escapes s for Markdown and defuses its @mentions.
+func markdownText(s string, opts options) string {
+	s = markdownEscaper.Replace(s)
+	if opts.noMentionEscape {
+		return s
+	}
+	return mentionPattern.ReplaceAllString(s, "$1`@$2`")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// netrcToken returns the password of the netrc entry for the host of
+// baseURL, for use as the token when GITHUB_TOKEN isn't set. The file is
+// $NETRC or ~/.netrc; a missing or unreadable file just means no token.
+func netrcToken(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return netrcPassword(string(data), u.Hostname())
+}
+
+// netrcPassword finds the password for host in the contents of a netrc
+// file: whitespace-separated "machine <host> login <name> password <secret>"
+// entries, plus an optional "default" entry for any other host. Macro
+// definitions aren't supported.
+func netrcPassword(data, host string) string {
+	fields := strings.Fields(data)
+	machine := "" // host of the entry being read, "" for the default one
+	inEntry := false
+	fallback := ""
+	for i := 0; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			i++
+			machine, inEntry = fields[i], true
+		case "default":
+			machine, inEntry = "", true
+		case "password":
+			i++
+			switch {
+			case !inEntry:
+			case machine == "":
+				fallback = fields[i]
+			case strings.EqualFold(machine, host):
+				return fields[i]
+			}
+		case "login", "account":
+			i++
+		}
+	}
+	return fallback
+}
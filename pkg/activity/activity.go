@@ -0,0 +1,189 @@
+// Package activity fetches and describes the public activity of GitHub
+// users: the events of the /users/{username}/events API. It is the part of
+// the github-activity command that other programs can embed.
+//
+// A Client fetches the events, and Describe or a Formatter turns them into
+// text:
+//
+//	client := activity.NewClient(os.Getenv("GITHUB_TOKEN"))
+//	events, err := client.FetchEvents(ctx, "octocat")
+//	if err != nil {
+//		return err
+//	}
+//	activity.TextFormatter{}.Format(os.Stdout, events)
+package activity
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Event represents a single event from the GitHub API.
+// We only define the fields we need to parse.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Actor     Actor     `json:"actor"`
+	Repo      Repo      `json:"repo"`
+	Org       Org       `json:"org"` // empty for repositories owned by a user
+	Payload   Payload   `json:"payload"`
+	Public    bool      `json:"public"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Actor is the account that triggered the event.
+type Actor struct {
+	Login string `json:"login"`
+}
+
+// Org is the organization owning an event's repository.
+type Org struct {
+	Login string `json:"login"`
+}
+
+// Repo contains information about the repository.
+type Repo struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Issue contains details about an issue or pull request.
+type Issue struct {
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	// PullRequest is only set when the issue is really a pull request,
+	// which is how GitHub reports comments on pull requests.
+	PullRequest *PullRequestLinks `json:"pull_request,omitempty"`
+	// Merged tells a merged pull request from one closed without merging.
+	Merged bool   `json:"merged,omitempty"`
+	URL    string `json:"url,omitempty"` // API URL, for looking up the current state
+}
+
+// PullRequestLinks marks an issue as a pull request.
+type PullRequestLinks struct {
+	HTMLURL string `json:"html_url"`
+}
+
+// CommitAuthor is the git author of a pushed commit, which isn't
+// necessarily the user who pushed it.
+type CommitAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Forkee contains information about the forked repository.
+type Forkee struct {
+	FullName string `json:"full_name"`
+}
+
+// Commit is one of the commits in a PushEvent.
+type Commit struct {
+	SHA      string       `json:"sha"`
+	Message  string       `json:"message"`
+	Author   CommitAuthor `json:"author"`
+	Distinct bool         `json:"distinct"` // false if the commit was already on another branch
+	URL      string       `json:"url"`      // API URL of the commit
+}
+
+// Payload contains event-specific details.
+//
+// GitHub sends null rather than omitting fields that don't apply, e.g.
+// "commits": null or "issue": null. The sub-objects are therefore plain
+// struct values, not pointers: encoding/json leaves a value untouched for
+// null, so the formatters can read them without nil checks.
+type Payload struct {
+	Action  string   `json:"action"`
+	Ref     string   `json:"ref"`
+	RefType string   `json:"ref_type"`
+	Before  string   `json:"before"` // PushEvent: SHA of the branch tip before the push
+	Head    string   `json:"head"`   // PushEvent: SHA of the branch tip after the push
+	Commits []Commit `json:"commits"`
+	// Size is how many commits a push had; Commits lists at most 20 of
+	// them. DistinctSize counts only those new to the repository, which
+	// leaves out commits a rebase or force-push merely moved.
+	Size         int     `json:"size"`
+	DistinctSize int     `json:"distinct_size"`
+	Issue        Issue   `json:"issue"`
+	Forkee       Forkee  `json:"forkee"`
+	PullRequest  Issue   `json:"pull_request"`
+	Release      Release `json:"release"`
+	Member       Actor   `json:"member"`  // MemberEvent: the collaborator added
+	Pages        []Wiki  `json:"pages"`   // GollumEvent: the wiki pages changed
+	Comment      Comment `json:"comment"` // CommitCommentEvent: the comment
+}
+
+// Wiki is a wiki page changed in a GollumEvent.
+type Wiki struct {
+	PageName string `json:"page_name"`
+	Action   string `json:"action"` // "created" or "edited"
+}
+
+// Comment contains the part of a comment needed to say what it is on.
+type Comment struct {
+	CommitID string `json:"commit_id"`
+}
+
+// Release contains information about a published release.
+type Release struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	HTMLURL string `json:"html_url"`
+}
+
+// ParseError records an event that was left out because it didn't match
+// the expected schema.
+type ParseError struct {
+	Index int    `json:"index"` // position in the API response
+	Error string `json:"error"`
+}
+
+// ParseEvents decodes a page of events one at a time, so that a single
+// event whose payload has drifted from the expected schema is skipped and
+// reported rather than failing the whole page. Bodies mangled on the way,
+// as some proxies and caches do, are taken as they were meant: an empty or
+// all-whitespace body has no events, a lone event object is a list of
+// one, and anything after the first JSON value is ignored. An error object
+// such as {"message": "..."} is reported as the error it is.
+func ParseEvents(body []byte) ([]Event, []ParseError, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, nil, nil
+	}
+	var first json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&first); err != nil {
+		return nil, nil, err
+	}
+
+	var raw []json.RawMessage
+	if first[0] == '{' {
+		var object struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(first, &object)
+		switch {
+		case object.Type != "":
+			raw = []json.RawMessage{first}
+		case object.Message != "":
+			return nil, nil, fmt.Errorf("got an error instead of events: %s", object.Message)
+		default:
+			return nil, nil, errors.New("got an object instead of a list of events")
+		}
+	} else if err := json.Unmarshal(first, &raw); err != nil {
+		return nil, nil, err
+	}
+	events := make([]Event, 0, len(raw))
+	var parseErrors []ParseError
+	for i, item := range raw {
+		var event Event
+		if err := json.Unmarshal(item, &event); err != nil {
+			parseErrors = append(parseErrors, ParseError{Index: i, Error: err.Error()})
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, parseErrors, nil
+}
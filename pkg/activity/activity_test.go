@@ -0,0 +1,92 @@
+package activity
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseEventsNullPayloadFields(t *testing.T) {
+	body, err := os.ReadFile("testdata/nulls.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, parseErrors, err := ParseEvents(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parseErrors) > 0 {
+		t.Fatalf("null fields made events fail to parse: %+v", parseErrors)
+	}
+	want := []string{
+		"Updated an issue in octocat/hello",
+		"Opened a pull request in octocat/hello",
+		"Forked octocat/hello",
+		"Published a release in octocat/hello",
+		"Pushed 0 commit(s) to octocat/hello",
+		"Updated 0 wiki page(s) in octocat/hello",
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if got := Describe(event, Style{}); got != want[i] {
+			t.Errorf("event %s: Describe() = %q, want %q", event.ID, got, want[i])
+		}
+	}
+	if !events[5].CreatedAt.IsZero() {
+		t.Errorf("a null created_at decoded as %v, want the zero time", events[5].CreatedAt)
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantIDs     []string
+		wantSkipped []int  // indexes reported as ParseErrors
+		wantErr     string // a part of the error; "" for none
+	}{
+		{name: "empty", body: ""},
+		{name: "whitespace", body: " \n\t "},
+		{name: "empty list", body: "[]"},
+		{name: "list", body: `[{"id":"1","type":"PushEvent"},{"id":"2","type":"WatchEvent"}]`, wantIDs: []string{"1", "2"}},
+		{name: "lone event object", body: `{"id":"7","type":"WatchEvent"}`, wantIDs: []string{"7"}},
+		{name: "trailing garbage", body: `[{"id":"1","type":"PushEvent"}]<!-- proxy -->`, wantIDs: []string{"1"}},
+		{name: "two lists", body: `[{"id":"1","type":"PushEvent"}] [{"id":"2","type":"PushEvent"}]`, wantIDs: []string{"1"}},
+		{name: "drifted payload", body: `[{"id":"1","type":"PushEvent","payload":{"size":"three"}},{"id":"2","type":"WatchEvent"}]`, wantIDs: []string{"2"}, wantSkipped: []int{0}},
+		{name: "error object", body: `{"message":"Not Found","documentation_url":"https://docs.github.com/rest"}`, wantErr: "got an error instead of events: Not Found"},
+		{name: "other object", body: `{"events":[]}`, wantErr: "got an object instead of a list"},
+		{name: "truncated", body: `[{"id":"1","type":"Pu`, wantErr: "unexpected EOF"},
+		{name: "not JSON", body: "<html>Bad gateway</html>", wantErr: "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, parseErrors, err := ParseEvents([]byte(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, event := range events {
+				ids = append(ids, event.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("events %v, want %v", ids, tt.wantIDs)
+			}
+			var skipped []int
+			for _, parseError := range parseErrors {
+				skipped = append(skipped, parseError.Index)
+			}
+			if !slices.Equal(skipped, tt.wantSkipped) {
+				t.Errorf("skipped %v, want %v", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
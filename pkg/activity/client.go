@@ -0,0 +1,173 @@
+package activity
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL is the address of the GitHub API. GitHub Enterprise
+// servers have theirs at https://<host>/api/v3.
+const DefaultBaseURL = "https://api.github.com"
+
+// DefaultAPIVersion pins the REST API version so that schema changes in
+// newer versions can't break parsing.
+const DefaultAPIVersion = "2022-11-28"
+
+// UserAgent identifies requests to GitHub, which rejects those without one.
+const UserAgent = "github-activity"
+
+// MaxEvents is how many events GitHub keeps per feed; later pages are
+// refused.
+const MaxEvents = 300
+
+// Client fetches events from the GitHub API. The zero Client works, sending
+// anonymous requests to DefaultBaseURL.
+type Client struct {
+	BaseURL    string       // "" means DefaultBaseURL
+	Token      string       // sent as a bearer token when set
+	APIVersion string       // "" means DefaultAPIVersion
+	HTTPClient *http.Client // nil means http.DefaultClient
+
+	// MaxBodySize caps how many bytes of a response are read; 0 means no
+	// cap. Larger responses fail with ErrTooLarge.
+	MaxBodySize int64
+}
+
+// NewClient returns a Client for api.github.com that authenticates with
+// token, or sends anonymous requests if token is "".
+func NewClient(token string) *Client {
+	return &Client{Token: token}
+}
+
+// APIError is a request the GitHub API answered with something other than
+// 200 OK.
+type APIError struct {
+	StatusCode int
+	Message    string      // GitHub's explanation, if it gave one
+	Header     http.Header // e.g. Retry-After, X-RateLimit-Remaining or Location
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("GitHub API returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("GitHub API returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// ErrTooLarge is returned for a response longer than Client.MaxBodySize.
+var ErrTooLarge = errors.New("response body too large")
+
+// Page is one page of a paginated response.
+type Page struct {
+	Body        []byte
+	Next        string      // URL of the following page, "" on the last one
+	Header      http.Header // the response headers, e.g. ETag and the rate limit
+	NotModified bool        // a 304 to a conditional request; Body is empty
+}
+
+// NewRequest builds a GET request for apiURL with the headers every API
+// call carries, including the token when one is set.
+func (c *Client) NewRequest(ctx context.Context, apiURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("X-GitHub-Api-Version", cmp.Or(c.APIVersion, DefaultAPIVersion))
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+// FetchEvents returns the recent events of username, newest first,
+// following the feed's pages until GitHub has no more or MaxEvents are in.
+// Events that don't parse are left out; ParseEvents reports them for
+// callers that fetch the pages themselves.
+func (c *Client) FetchEvents(ctx context.Context, username string) ([]Event, error) {
+	base := strings.TrimSuffix(cmp.Or(c.BaseURL, DefaultBaseURL), "/")
+	next := fmt.Sprintf("%s/users/%s/events", base, url.PathEscape(username))
+	var events []Event
+	for next != "" && len(events) < MaxEvents {
+		pg, err := c.FetchPage(ctx, next)
+		if err != nil {
+			return events, err
+		}
+		page, _, err := ParseEvents(pg.Body)
+		if err != nil {
+			return events, fmt.Errorf("parsing events of %s: %w", username, err)
+		}
+		events = append(events, page...)
+		next = pg.Next
+	}
+	return events[:min(len(events), MaxEvents)], nil
+}
+
+// FetchPage fetches a single page of apiURL.
+func (c *Client) FetchPage(ctx context.Context, apiURL string) (Page, error) {
+	req, err := c.NewRequest(ctx, apiURL)
+	if err != nil {
+		return Page{}, err
+	}
+	return c.Do(req)
+}
+
+// Do sends req, which NewRequest built, and reads the page it answers
+// with. A status other than 200 OK is an *APIError, except for a 304 to a
+// request carrying If-None-Match, which is a Page with NotModified set.
+func (c *Client) Do(req *http.Request) (Page, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Page{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && req.Header.Get("If-None-Match") != "" {
+		return Page{Header: resp.Header, NotModified: true}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr)
+		return Page{}, &APIError{StatusCode: resp.StatusCode, Message: apiErr.Message, Header: resp.Header}
+	}
+	body := io.Reader(resp.Body)
+	if c.MaxBodySize > 0 {
+		body = io.LimitReader(resp.Body, c.MaxBodySize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return Page{}, fmt.Errorf("reading the response: %w", err)
+	}
+	if c.MaxBodySize > 0 && int64(len(data)) > c.MaxBodySize {
+		return Page{}, ErrTooLarge
+	}
+	return Page{Body: data, Next: NextPageURL(resp.Header.Get("Link")), Header: resp.Header}, nil
+}
+
+// NextPageURL extracts the rel="next" target from a Link header such as
+// `<https://api.github.com/...?page=2>; rel="next", <...>; rel="last"`.
+func NextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		target = strings.TrimSpace(target)
+		return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	}
+	return ""
+}
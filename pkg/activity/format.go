@@ -0,0 +1,306 @@
+package activity
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Formatter writes events in one output format. Everything else it needs,
+// such as a heading or settings, is given when it is built.
+type Formatter interface {
+	Format(w io.Writer, events []Event) error
+}
+
+// FormatterFunc adapts a function to the Formatter interface.
+type FormatterFunc func(w io.Writer, events []Event) error
+
+// Format calls fn(w, events).
+func (fn FormatterFunc) Format(w io.Writer, events []Event) error {
+	return fn(w, events)
+}
+
+// TextFormatter writes one "- ..." line per event, as Describe puts it.
+type TextFormatter struct {
+	Style Style
+}
+
+func (t TextFormatter) Format(w io.Writer, events []Event) error {
+	for _, event := range events {
+		if _, err := fmt.Fprintf(w, "- %s\n", Describe(event, t.Style)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Style holds the choices Describe leaves open. The zero Style is the
+// plain sentence.
+type Style struct {
+	RepoName        func(repo Repo) string // how to name repositories; nil means RepoName
+	ShowSHA         bool                   // add the commit range to push lines
+	DistinctCommits bool                   // count only the commits new to the repository
+	Verbose         bool                   // add how many pushed commits are new
+}
+
+// describer describes one event type with first-class formatting.
+type describer struct {
+	explain string // what the event means, for Explain
+	format  func(event Event, repo string, style Style) string
+}
+
+// describers maps every event type that gets first-class formatting to
+// the function describing it and its explanation. It is the one list of
+// supported types: Describe dispatches through it and SupportedTypes
+// lists it, so the two can't drift apart, and neither can a type and its
+// explanation. Anything else falls back to a generic sentence.
+var describers = map[string]describer{
+	"PushEvent": {"added commits to a branch", func(event Event, repo string, style Style) string {
+		if style.ShowSHA && event.Payload.Head != "" {
+			return fmt.Sprintf("Pushed %s to %s (%s) in %s", pushedCommits(event.Payload, style), pushedBranch(event.Payload.Ref), shaRange(event.Payload.Before, event.Payload.Head), repo)
+		}
+		return fmt.Sprintf("Pushed %s to %s", pushedCommits(event.Payload, style), repo)
+	}},
+	"CreateEvent": {"made a new repository, branch or tag", func(event Event, repo string, style Style) string {
+		// For a new repository the repo name already says what was created.
+		if event.Payload.RefType == "repository" {
+			return fmt.Sprintf("Created repository %s", repo)
+		}
+		if event.Payload.Ref == "" {
+			return fmt.Sprintf("Created a new %s in %s", refType(event.Payload.RefType), repo)
+		}
+		return fmt.Sprintf("Created a new %s %s in %s", refType(event.Payload.RefType), shortRef(event.Payload.Ref), repo)
+	}},
+	"DeleteEvent": {"removed a branch or tag", func(event Event, repo string, style Style) string {
+		if event.Payload.Ref == "" {
+			return fmt.Sprintf("Deleted a %s in %s", refType(event.Payload.RefType), repo)
+		}
+		return fmt.Sprintf("Deleted %s %s in %s", refType(event.Payload.RefType), shortRef(event.Payload.Ref), repo)
+	}},
+	"IssuesEvent": {"changed an issue, e.g. opened or closed it", func(event Event, repo string, style Style) string {
+		return fmt.Sprintf("%s an issue in %s%s", ActionVerb(event.Payload.Action, "Updated"), repo, quotedTitle(event.Payload.Issue.Title))
+	}},
+	"IssueCommentEvent": {"wrote a comment on an issue or pull request", func(event Event, repo string, style Style) string {
+		if event.Payload.Issue.PullRequest != nil {
+			return fmt.Sprintf("Commented on a pull request in %s%s", repo, quotedTitle(event.Payload.Issue.Title))
+		}
+		return fmt.Sprintf("Commented on an issue in %s%s", repo, quotedTitle(event.Payload.Issue.Title))
+	}},
+	"WatchEvent": {"starred the repository, bookmarking it", func(event Event, repo string, style Style) string {
+		return fmt.Sprintf("%s watching %s", ActionVerb(event.Payload.Action, "Started"), repo)
+	}},
+	"ForkEvent": {"made their own copy of the repository", func(event Event, repo string, style Style) string {
+		if event.Payload.Forkee.FullName == "" {
+			return fmt.Sprintf("Forked %s", repo)
+		}
+		return fmt.Sprintf("Forked %s to %s", repo, event.Payload.Forkee.FullName)
+	}},
+	"PullRequestEvent": {"changed a proposal to merge code, e.g. opened or merged it", func(event Event, repo string, style Style) string {
+		return fmt.Sprintf("%s a pull request in %s%s", ActionVerb(event.Payload.Action, "Updated"), repo, quotedTitle(event.Payload.PullRequest.Title))
+	}},
+	"ReleaseEvent": {"published a version of the project for download", func(event Event, repo string, style Style) string {
+		if event.Payload.Release.TagName == "" {
+			return fmt.Sprintf("Published a release in %s", repo)
+		}
+		return fmt.Sprintf("Published release %s in %s", event.Payload.Release.TagName, repo)
+	}},
+	"PullRequestReviewEvent": {"reviewed the code changes of a pull request", func(event Event, repo string, style Style) string {
+		return fmt.Sprintf("Reviewed a pull request in %s%s", repo, quotedTitle(event.Payload.PullRequest.Title))
+	}},
+	"PullRequestReviewCommentEvent": {"commented on a line of code in a pull request", func(event Event, repo string, style Style) string {
+		return fmt.Sprintf("Commented on a pull request review in %s%s", repo, quotedTitle(event.Payload.PullRequest.Title))
+	}},
+	"CommitCommentEvent": {"commented on a single commit", func(event Event, repo string, style Style) string {
+		if event.Payload.Comment.CommitID == "" {
+			return fmt.Sprintf("Commented on a commit in %s", repo)
+		}
+		return fmt.Sprintf("Commented on commit %s in %s", ShortSHA(event.Payload.Comment.CommitID), repo)
+	}},
+	"MemberEvent": {"gave someone write access to the repository", func(event Event, repo string, style Style) string {
+		if event.Payload.Member.Login == "" {
+			return fmt.Sprintf("%s a collaborator in %s", ActionVerb(event.Payload.Action, "Changed"), repo)
+		}
+		return fmt.Sprintf("%s %s as a collaborator to %s", ActionVerb(event.Payload.Action, "Added"), event.Payload.Member.Login, repo)
+	}},
+	"GollumEvent": {"edited the repository's wiki", func(event Event, repo string, style Style) string {
+		if pages := event.Payload.Pages; len(pages) == 1 {
+			return fmt.Sprintf("%s wiki page %s in %s", ActionVerb(pages[0].Action, "Updated"), pages[0].PageName, repo)
+		}
+		return fmt.Sprintf("Updated %d wiki page(s) in %s", len(event.Payload.Pages), repo)
+	}},
+	"SponsorshipEvent": {"changed a GitHub Sponsors sponsorship", func(event Event, repo string, style Style) string {
+		return fmt.Sprintf("%s a sponsorship in %s", ActionVerb(event.Payload.Action, "Changed"), repo)
+	}},
+	"PublicEvent": {"made a private repository public", func(event Event, repo string, style Style) string {
+		return fmt.Sprintf("Made %s public", repo)
+	}},
+}
+
+// Describe puts a single event as a short human-readable sentence, such
+// as "Pushed 3 commit(s) to octocat/Hello-World".
+func Describe(event Event, style Style) string {
+	repo := RepoName(event.Repo)
+	if style.RepoName != nil {
+		repo = style.RepoName(event.Repo)
+	}
+	if d, ok := describers[event.Type]; ok {
+		return d.format(event, repo, style)
+	}
+	return fmt.Sprintf("Performed a %s on %s", event.Type, repo)
+}
+
+// RepoName is the name to show for an event's repository. The API sends
+// a null repo for deleted repositories, which would otherwise leave a
+// dangling "to " at the end of the line.
+func RepoName(repo Repo) string {
+	if repo.Name != "" {
+		return repo.Name
+	}
+	if repo.ID != 0 {
+		return fmt.Sprintf("repository #%d", repo.ID)
+	}
+	return "a deleted repository"
+}
+
+// Explain says in a few words what an event type means, e.g. "added
+// commits to a branch" for PushEvent, or "" for an unsupported type.
+func Explain(eventType string) string {
+	return describers[eventType].explain
+}
+
+// Supported reports whether eventType has first-class formatting rather
+// than Describe's generic sentence.
+func Supported(eventType string) bool {
+	_, ok := describers[eventType]
+	return ok
+}
+
+// SupportedTypes lists the event types with first-class formatting, sorted.
+func SupportedTypes() []string {
+	types := make([]string, 0, len(describers))
+	for eventType := range describers {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// githubTypes lists every event type GitHub documents for the events API,
+// whether or not it has first-class formatting here.
+var githubTypes = []string{
+	"CommitCommentEvent", "CreateEvent", "DeleteEvent", "DiscussionEvent",
+	"ForkEvent", "GollumEvent", "IssueCommentEvent", "IssuesEvent",
+	"MemberEvent", "PublicEvent", "PullRequestEvent", "PullRequestReviewCommentEvent",
+	"PullRequestReviewEvent", "PullRequestReviewThreadEvent", "PushEvent",
+	"ReleaseEvent", "SponsorshipEvent", "WatchEvent",
+}
+
+// Known reports whether eventType is one GitHub documents, so it can turn
+// up in a feed even if it isn't Supported.
+func Known(eventType string) bool {
+	return slices.Contains(githubTypes, eventType) || Supported(eventType)
+}
+
+// KnownTypes lists the event types GitHub documents, sorted.
+func KnownTypes() []string {
+	types := slices.Clone(githubTypes)
+	for _, eventType := range SupportedTypes() {
+		if !slices.Contains(types, eventType) {
+			types = append(types, eventType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ActionVerb capitalizes a payload's action for the start of a sentence,
+// using fallback when the action is missing or null.
+func ActionVerb(action, fallback string) string {
+	if action == "" {
+		return fallback
+	}
+	return strings.Title(action)
+}
+
+// ShortSHA abbreviates a commit SHA to the usual 7 characters.
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// shaRange renders a push's commit range as "abc1234..def5678". A branch's
+// first push has an all-zero "before" SHA, so only the head is shown.
+func shaRange(before, head string) string {
+	if strings.Trim(before, "0") == "" {
+		return ShortSHA(head)
+	}
+	return ShortSHA(before) + ".." + ShortSHA(head)
+}
+
+// pushedCommits is the "3 commit(s)" of a push line. It counts size, or
+// with DistinctCommits distinct_size, falling back to the listed commits
+// for payloads without the counts. Verbose adds how many are new, e.g.
+// "3 commit(s) (2 new)", when that differs.
+func pushedCommits(p Payload, style Style) string {
+	size := p.Size
+	if size == 0 {
+		size = len(p.Commits)
+	}
+	distinct := p.DistinctSize
+	if distinct == 0 {
+		for _, commit := range p.Commits {
+			if commit.Distinct {
+				distinct++
+			}
+		}
+	}
+	if style.DistinctCommits {
+		return fmt.Sprintf("%d commit(s)", distinct)
+	}
+	if style.Verbose && distinct != size {
+		return fmt.Sprintf("%d commit(s) (%d new)", size, distinct)
+	}
+	return fmt.Sprintf("%d commit(s)", size)
+}
+
+// quotedTitle is the `: "title"` ending of issue and pull request lines, or
+// nothing when the title is missing.
+func quotedTitle(title string) string {
+	if title == "" {
+		return ""
+	}
+	return fmt.Sprintf(": \"%s\"", title)
+}
+
+// refType names what a Create or Delete event was about, falling back to
+// "ref" when GitHub doesn't say.
+func refType(t string) string {
+	if t == "" {
+		return "ref"
+	}
+	return t
+}
+
+// pushedBranch names the branch of a push line, or says "a branch" when
+// the payload doesn't, rather than leaving a gap in the sentence.
+func pushedBranch(ref string) string {
+	if ref == "" {
+		return "a branch"
+	}
+	return shortRef(ref)
+}
+
+// shortRef strips the refs/heads/ or refs/tags/ prefix GitHub sometimes
+// includes, so "refs/heads/main" and "main" both read as "main".
+func shortRef(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if short, ok := strings.CutPrefix(ref, prefix); ok {
+			return short
+		}
+	}
+	return ref
+}
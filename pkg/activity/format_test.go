@@ -0,0 +1,176 @@
+package activity
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		style Style
+		want  string
+	}{
+		{
+			name:  "deleted repository",
+			event: Event{Type: "WatchEvent", Payload: Payload{Action: "started"}},
+			want:  "Started watching a deleted repository",
+		},
+		{
+			name:  "repository known only by ID",
+			event: Event{Type: "PushEvent", Repo: Repo{ID: 42}, Payload: Payload{Size: 1}},
+			want:  "Pushed 1 commit(s) to repository #42",
+		},
+		{
+			name:  "unsupported type on a deleted repository",
+			event: Event{Type: "FooEvent"},
+			want:  "Performed a FooEvent on a deleted repository",
+		},
+		{
+			name:  "comment on an issue",
+			event: Event{Type: "IssueCommentEvent", Repo: Repo{Name: "o/r"}, Payload: Payload{Issue: Issue{Title: "Bug"}}},
+			want:  `Commented on an issue in o/r: "Bug"`,
+		},
+		{
+			name:  "comment on a pull request",
+			event: Event{Type: "IssueCommentEvent", Repo: Repo{Name: "o/r"}, Payload: Payload{Issue: Issue{Title: "Fix", PullRequest: &PullRequestLinks{}}}},
+			want:  `Commented on a pull request in o/r: "Fix"`,
+		},
+		{
+			name:  "fork with its copy named",
+			event: Event{Type: "ForkEvent", Repo: Repo{Name: "o/r"}, Payload: Payload{Forkee: Forkee{FullName: "me/r"}}},
+			want:  "Forked o/r to me/r",
+		},
+		{
+			name:  "fork without a forkee name",
+			event: Event{Type: "ForkEvent", Repo: Repo{Name: "o/r"}},
+			want:  "Forked o/r",
+		},
+		{
+			name:  "tag created with a short ref",
+			event: Event{Type: "CreateEvent", Repo: Repo{Name: "o/r"}, Payload: Payload{RefType: "tag", Ref: "v1.0"}},
+			want:  "Created a new tag v1.0 in o/r",
+		},
+		{
+			name:  "tag created with a full ref",
+			event: Event{Type: "CreateEvent", Repo: Repo{Name: "o/r"}, Payload: Payload{RefType: "tag", Ref: "refs/tags/v1.0"}},
+			want:  "Created a new tag v1.0 in o/r",
+		},
+		{
+			name:  "branch deleted with a full ref",
+			event: Event{Type: "DeleteEvent", Repo: Repo{Name: "o/r"}, Payload: Payload{RefType: "branch", Ref: "refs/heads/feature/x"}},
+			want:  "Deleted branch feature/x in o/r",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Describe(tt.event, tt.style); got != tt.want {
+				t.Errorf("Describe() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeNullRepo(t *testing.T) {
+	events, _, err := ParseEvents([]byte(`[{"id":"1","type":"ForkEvent","repo":null,"payload":{"forkee":null}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := Describe(events[0], Style{}), "Forked a deleted repository"; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+}
+
+func TestIssueCommentOnPullRequestDecodes(t *testing.T) {
+	body := `[
+		{"id":"1","type":"IssueCommentEvent","repo":{"name":"o/r"},"payload":{"issue":{"title":"Bug"}}},
+		{"id":"2","type":"IssueCommentEvent","repo":{"name":"o/r"},"payload":{"issue":{"title":"Fix","pull_request":{"url":"https://api.github.com/repos/o/r/pulls/2"}}}}
+	]`
+	events, _, err := ParseEvents([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{`Commented on an issue in o/r: "Bug"`, `Commented on a pull request in o/r: "Fix"`} {
+		if got := Describe(events[i], Style{}); got != want {
+			t.Errorf("event %d: Describe() = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestDescribeNewerTypes(t *testing.T) {
+	body, err := os.ReadFile("testdata/newer_types.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, parseErrors, err := ParseEvents(body)
+	if err != nil || len(parseErrors) > 0 {
+		t.Fatalf("ParseEvents: %v %+v", err, parseErrors)
+	}
+	want := []string{
+		`Reviewed a pull request in octocat/hello: "Add caching"`,
+		`Commented on a pull request review in octocat/hello: "Add caching"`,
+		"Commented on commit 6dcb09b in octocat/hello",
+		"Added hubot as a collaborator to octocat/hello",
+		"Edited wiki page Home in octocat/hello",
+		"Updated 2 wiki page(s) in octocat/hello",
+		"Created a sponsorship in octocat/hello",
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if !Supported(event.Type) {
+			t.Errorf("%s isn't a supported type", event.Type)
+		}
+		if got := Describe(event, Style{}); got != want[i] {
+			t.Errorf("%s: Describe() = %q, want %q", event.Type, got, want[i])
+		}
+	}
+}
+
+func TestDescribeDistinctCommits(t *testing.T) {
+	body, err := os.ReadFile("testdata/distinct_push.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _, err := ParseEvents(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		event Event
+		style Style
+		want  string
+	}{
+		{"size", events[0], Style{}, "Pushed 3 commit(s) to octocat/hello"},
+		{"distinct_size", events[0], Style{DistinctCommits: true}, "Pushed 1 commit(s) to octocat/hello"},
+		{"both when verbose", events[0], Style{Verbose: true}, "Pushed 3 commit(s) (1 new) to octocat/hello"},
+		{"listed commits without the counts", events[1], Style{}, "Pushed 2 commit(s) to octocat/hello"},
+		{"listed distinct commits without the counts", events[1], Style{DistinctCommits: true}, "Pushed 1 commit(s) to octocat/hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Describe(tt.event, tt.style); got != tt.want {
+				t.Errorf("Describe() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnownTypes(t *testing.T) {
+	for _, eventType := range SupportedTypes() {
+		if !Known(eventType) {
+			t.Errorf("supported type %s isn't known", eventType)
+		}
+	}
+	for _, eventType := range []string{"PullRequestReviewThreadEvent", "DiscussionEvent"} {
+		if !Known(eventType) || Supported(eventType) {
+			t.Errorf("%s: Known() = %v, Supported() = %v, want true, false", eventType, Known(eventType), Supported(eventType))
+		}
+	}
+	if Known("pushevent") {
+		t.Error("Known() accepted a wrongly capitalized type")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// progress tracks the fetches of one run: it drives the spinner and counts
+// pages and events for the --stats-line summary. Fetches for several users
+// share one progress, so it's safe for concurrent use.
+type progress struct {
+	sp      *spinner
+	started time.Time
+
+	mu     sync.Mutex
+	pages  int
+	events int
+}
+
+// newProgress starts sp (which may be nil) and the run's clock.
+func newProgress(sp *spinner) *progress {
+	sp.SetPage(1)
+	sp.Start()
+	return &progress{sp: sp, started: time.Now()}
+}
+
+// fetchingPage reports that page n of some feed is being requested.
+func (p *progress) fetchingPage(n int) {
+	p.sp.SetPage(n)
+}
+
+// pageFetched records a page that came back with the given number of events.
+func (p *progress) pageFetched(events int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pages++
+	p.events += events
+}
+
+// done stops the spinner so that output can be printed.
+func (p *progress) done() {
+	p.sp.Stop()
+}
+
+// writeStats writes a one-line summary such as
+// "Fetched 247 events across 9 pages in 3.2s".
+func (p *progress) writeStats(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(w, "Fetched %d events across %d pages in %.1fs\n", p.events, p.pages, time.Since(p.started).Seconds())
+}
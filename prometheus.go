@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// promEscaper escapes label values as the Prometheus text exposition
+// format requires.
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writePrometheus renders the events as Prometheus metrics, so that a
+// periodic run can serve as a simple exporter. Each metric has one sample
+// per user whose feed didn't fail:
+//
+//	github_user_events_total{user="octocat",type="PushEvent"} 5
+//	github_user_last_event_timestamp{user="octocat"} 1714557600
+func writePrometheus(w io.Writer, sections []section, opts options) error {
+	fmt.Fprintln(w, "# HELP github_user_events_total Events in the recent activity feed, by event type.")
+	fmt.Fprintln(w, "# TYPE github_user_events_total gauge")
+	for _, s := range sections {
+		if s.err != nil {
+			continue
+		}
+		user := promEscaper.Replace(s.feed.login)
+		for _, row := range countBy(s.events, "type", location(opts)) {
+			fmt.Fprintf(w, "github_user_events_total{user=\"%s\",type=\"%s\"} %d\n", user, promEscaper.Replace(row.Key), row.Count)
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP github_user_last_event_timestamp Unix time of the most recent event.")
+	fmt.Fprintln(w, "# TYPE github_user_last_event_timestamp gauge")
+	for _, s := range sections {
+		if s.err != nil {
+			continue
+		}
+		var last int64
+		for _, event := range s.events {
+			if t := event.CreatedAt.Unix(); !event.CreatedAt.IsZero() && t > last {
+				last = t
+			}
+		}
+		if _, err := fmt.Fprintf(w, "github_user_last_event_timestamp{user=\"%s\"} %d\n", promEscaper.Replace(s.feed.login), last); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSecondaryRetries is how many times a request refused by a secondary
+// rate limit is sent again before giving up.
+const maxSecondaryRetries = 2
+
+// defaultRetryAfter is the wait after a secondary rate limit when GitHub
+// doesn't send a Retry-After header; its docs ask for at least a minute.
+const defaultRetryAfter = time.Minute
+
+// secondaryLimitError is a request refused by one of GitHub's secondary
+// rate limits, which guard against bursts rather than counting requests
+// per hour. Unlike the primary limit they lift again after a short wait,
+// so the request is worth retrying.
+type secondaryLimitError struct {
+	err        error
+	retryAfter time.Duration // how long GitHub asked to wait
+}
+
+func (e *secondaryLimitError) Error() string { return e.err.Error() }
+func (e *secondaryLimitError) Unwrap() error { return e.err }
+
+// isSecondaryLimit reports whether an API error message is about the
+// secondary rate limits. GitHub words it as, e.g., "You have exceeded a
+// secondary rate limit. Please wait a few minutes before you try again."
+func isSecondaryLimit(message string) bool {
+	return strings.Contains(strings.ToLower(message), "secondary rate limit")
+}
+
+// retryAfter reads the Retry-After header, which holds either a number of
+// seconds or an HTTP date, falling back to defaultRetryAfter.
+func retryAfter(header http.Header) time.Duration {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return defaultRetryAfter
+}
+
+// fetchPageRetrying is fetchPage, sending the request again after the
+// Retry-After wait when a secondary rate limit refuses it. It gives up
+// straight away when the wait would run past --deadline.
+func fetchPageRetrying(ctx context.Context, apiURL, subject string, opts options) (page, error) {
+	for attempt := 0; ; attempt++ {
+		pg, err := fetchPage(ctx, apiURL, subject, opts)
+		var limited *secondaryLimitError
+		if !errors.As(err, &limited) || attempt >= maxSecondaryRetries {
+			return pg, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(limited.retryAfter).After(deadline) {
+			return pg, err
+		}
+
+		if !opts.quiet {
+			fmt.Fprintf(os.Stderr, "Note: hit GitHub's secondary rate limit; retrying in %v.\n", limited.retryAfter)
+		}
+		select {
+		case <-ctx.Done():
+			return pg, err
+		case <-time.After(limited.retryAfter):
+		}
+	}
+}
+
+// slowDownBelow is the X-RateLimit-Remaining under which waitForPage
+// spreads the remaining requests out until the limit resets.
+const slowDownBelow = 50
+
+// maxPageWait caps how long waitForPage waits for the rate limit.
+const maxPageWait = time.Minute
+
+// withRateLimit records the rate-limit headers of a response in pg.
+func withRateLimit(pg page, header http.Header) page {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return pg
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		pg.remaining, pg.reset = remaining, time.Unix(reset, 0)
+	}
+	return pg
+}
+
+// waitForPage pauses before the page after pg: for --page-delay, and, once
+// the rate limit runs low, long enough to spread the requests left over
+// the time until it resets. The wait ends early with an error when the
+// --deadline passes.
+func waitForPage(ctx context.Context, pg page, opts options) error {
+	wait := opts.pageDelay
+	if !pg.reset.IsZero() && pg.remaining < slowDownBelow {
+		spread := time.Until(pg.reset) / time.Duration(pg.remaining+1)
+		wait = max(wait, min(spread, maxPageWait))
+	}
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return withExitCode(exitNetwork, fmt.Errorf("Gave up after the --deadline of %v.", opts.deadline))
+	case <-time.After(wait):
+		return nil
+	}
+}
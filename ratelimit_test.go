@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30", 30 * time.Second},
+		{" 0 ", 0},
+		{"", defaultRetryAfter},
+		{"soon", defaultRetryAfter},
+		{"-5", defaultRetryAfter},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0}, // a date in the past
+	}
+	for _, tt := range tests {
+		header := http.Header{"Retry-After": {tt.value}}
+		if got := retryAfter(header); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFetchPageRetryingWaitsOutSecondaryLimit(t *testing.T) {
+	limited, err := os.ReadFile("testdata/secondary_rate_limit.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		status int // how GitHub refuses the first request
+	}{
+		{"403", http.StatusForbidden},
+		{"error object with a 200", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(tt.status)
+					w.Write(limited)
+					return
+				}
+				w.Write([]byte(`[{"id":"1","type":"WatchEvent"}]`))
+			}))
+			defer srv.Close()
+
+			opts := options{maxBodySize: 1 << 20, noCache: true, quiet: true}
+			start := time.Now()
+			pg, err := fetchPageRetrying(context.Background(), srv.URL+"/users/alice/events", "alice", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if requests != 2 {
+				t.Errorf("sent %d requests, want 2", requests)
+			}
+			if waited := time.Since(start); waited < time.Second {
+				t.Errorf("retried after %v, before the Retry-After of 1s", waited)
+			}
+			if string(pg.body) != `[{"id":"1","type":"WatchEvent"}]` {
+				t.Errorf("body = %s, want the retried response", pg.body)
+			}
+		})
+	}
+}
+
+func TestFetchPageRetryingGivesUpPastDeadline(t *testing.T) {
+	limited, err := os.ReadFile("testdata/secondary_rate_limit.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write(limited)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = fetchPageRetrying(ctx, srv.URL+"/users/alice/events", "alice", options{maxBodySize: 1 << 20, noCache: true, quiet: true})
+	var secondary *secondaryLimitError
+	if !errors.As(err, &secondary) || secondary.retryAfter != time.Hour {
+		t.Fatalf("err = %v, want a secondary limit asking to wait an hour", err)
+	}
+	if code := exitCode(err); code != exitRateLimited {
+		t.Errorf("exit code %d, want %d", code, exitRateLimited)
+	}
+	if requests != 1 {
+		t.Errorf("sent %d requests, want no retry that would overrun --deadline", requests)
+	}
+}
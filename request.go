@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+// newRequest builds a GET request for apiURL with the headers every API
+// call carries, including the token when one is configured.
+func newRequest(ctx context.Context, apiURL string, opts options) (*http.Request, error) {
+	client := activity.Client{Token: opts.token, APIVersion: opts.apiVersion}
+	req, err := client.NewRequest(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	// An empty --api-version leaves the choice to GitHub.
+	if opts.apiVersion == "" {
+		req.Header.Del("X-GitHub-Api-Version")
+	}
+	return req, nil
+}
+
+// apiClient returns the library client that sends requests built by
+// newRequest through newHTTPClient.
+func apiClient(opts options) *activity.Client {
+	return &activity.Client{
+		Token:       opts.token,
+		APIVersion:  opts.apiVersion,
+		HTTPClient:  newHTTPClient(opts),
+		MaxBodySize: opts.maxBodySize,
+	}
+}
+
+// newHTTPClient returns a client that follows at most --max-redirects
+// redirects and, with --debug, logs its traffic. GitHub answers requests
+// for a renamed user with a redirect to the new login, so that case is
+// pointed out rather than followed silently.
+func newHTTPClient(opts options) *http.Client {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if opts.maxRedirects <= 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > opts.maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", opts.maxRedirects)
+			}
+			from, to := userInPath(via[len(via)-1].URL.Path), userInPath(req.URL.Path)
+			if from != "" && to != "" && !strings.EqualFold(from, to) && !opts.quiet {
+				fmt.Fprintf(os.Stderr, "Note: user '%s' was renamed to '%s'.\n", from, to)
+			}
+			return nil
+		},
+	}
+	if opts.debug {
+		client.Transport = debugTransport{base: http.DefaultTransport, w: os.Stderr, redact: opts.redact}
+	}
+	return client
+}
+
+// userInPath returns the login in an API path such as /users/octocat/events,
+// or "" if the path isn't about a user.
+func userInPath(path string) string {
+	_, rest, ok := strings.Cut(path, "/users/")
+	if !ok {
+		return ""
+	}
+	login, _, _ := strings.Cut(rest, "/")
+	return login
+}
+
+// dryRun prints the request that would be sent for username without
+// sending it, so URL and header configuration can be checked without
+// spending rate limit.
+func dryRun(ctx context.Context, w io.Writer, username string, opts options) error {
+	apiURL, _ := feedURL(username, opts)
+	req, err := newRequest(ctx, apiURL, opts)
+	if err != nil {
+		return fmt.Errorf("Could not build the request. Reason: %v", err)
+	}
+	printRequest(w, req)
+	if needsTokenOwner(opts) {
+		opts.publicEvents = true
+		if publicURL, _ := feedURL(username, opts); publicURL != apiURL {
+			fmt.Fprintf(w, "(Unless the token belongs to %s, %s is requested instead.)\n", username, publicURL)
+		}
+	}
+	return nil
+}
+
+// printRequest writes the method, URL and headers of req, one header per
+// line in a stable order, with credentials redacted.
+func printRequest(w io.Writer, req *http.Request) {
+	fmt.Fprintf(w, "%s %s\n", req.Method, req.URL)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(w, "%s: %s\n", name, redactHeader(name, value))
+		}
+	}
+}
+
+// redactHeader hides the secret part of credential headers. The scheme is
+// kept ("Bearer ***") so it's still clear what kind of auth was sent.
+func redactHeader(name, value string) string {
+	if http.CanonicalHeaderKey(name) != "Authorization" {
+		return value
+	}
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " ***"
+	}
+	return "***"
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+func TestAPIVersionHeaderIsSent(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Values("X-GitHub-Api-Version")
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		apiVersion string
+		want       []string
+	}{
+		{activity.DefaultAPIVersion, []string{"2022-11-28"}},
+		{"2026-03-10", []string{"2026-03-10"}},
+		{"", nil}, // left to GitHub
+	}
+	for _, tt := range tests {
+		got = nil
+		opts := options{apiVersion: tt.apiVersion, maxBodySize: 1 << 20, noCache: true}
+		if _, err := fetchPage(context.Background(), srv.URL+"/users/alice/events", "alice", opts); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tt.want) || len(got) > 0 && got[0] != tt.want[0] {
+			t.Errorf("--api-version %q sent X-GitHub-Api-Version %q, want %q", tt.apiVersion, got, tt.want)
+		}
+	}
+}
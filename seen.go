@@ -0,0 +1,62 @@
+package main
+
+import "fmt"
+
+// seenCacheFile holds the IDs of the events --only-new has already shown,
+// least recently seen first.
+const seenCacheFile = "seen.json"
+
+// maxSeenIDs caps the seen-events cache. It only has to outlast the ~300
+// events GitHub keeps per feed, with room for a few feeds.
+const maxSeenIDs = 5000
+
+// onlyNew drops the events shown by an earlier --only-new run and records
+// the rest as seen. Unlike --since, this doesn't depend on events arriving
+// in order: one that GitHub delivers late is still new. IDs seen again are
+// moved to the back so that the cap evicts the least recently seen first.
+func onlyNew(events []Event) ([]Event, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	var ids []string
+	if err := readCache(seenCacheFile, &ids); err != nil {
+		return nil, fmt.Errorf("Failed to read the seen-events cache. Reason: %v", err)
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+
+	var fresh []Event
+	touched := make(map[string]bool)
+	for _, event := range events {
+		if event.ID == "" {
+			continue
+		}
+		if !seen[event.ID] {
+			fresh = append(fresh, event)
+		}
+		touched[event.ID] = true
+	}
+
+	kept := make([]string, 0, len(ids)+len(touched))
+	for _, id := range ids {
+		if !touched[id] {
+			kept = append(kept, id)
+		}
+	}
+	// Append in feed order reversed, so that the newest event ends up last.
+	for i := len(events) - 1; i >= 0; i-- {
+		if id := events[i].ID; touched[id] {
+			kept = append(kept, id)
+			delete(touched, id)
+		}
+	}
+	if len(kept) > maxSeenIDs {
+		kept = kept[len(kept)-maxSeenIDs:]
+	}
+	if err := writeCache(seenCacheFile, kept); err != nil {
+		return nil, fmt.Errorf("Failed to write the seen-events cache. Reason: %v", err)
+	}
+	return fresh, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+func TestOnlyNewAcrossTwoRuns(t *testing.T) {
+	saved := cacheOverride
+	cacheOverride = t.TempDir()
+	t.Cleanup(func() { cacheOverride = saved })
+
+	feed := func(ids ...string) []Event {
+		var events []Event
+		for _, id := range ids {
+			events = append(events, Event{Event: activity.Event{ID: id, Type: "WatchEvent"}})
+		}
+		return events
+	}
+
+	first, err := onlyNew(feed("103", "102", "101"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := eventIDs(first); !slices.Equal(got, []string{"103", "102", "101"}) {
+		t.Errorf("first run showed %v, want every event", got)
+	}
+
+	// The second run sees two new events, one of them delivered late with
+	// an ID below those already seen, which --since would have missed.
+	second, err := onlyNew(feed("104", "103", "99", "102"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := eventIDs(second); !slices.Equal(got, []string{"104", "99"}) {
+		t.Errorf("second run showed %v, want only 104 and 99", got)
+	}
+
+	var ids []string
+	if err := readCache(seenCacheFile, &ids); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"101", "102", "99", "103", "104"}; !slices.Equal(ids, want) {
+		t.Errorf("seen.json holds %v, want %v, least recently seen first", ids, want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// pageSource supplies the pages of a feed. Normally they come from the API,
+// but --input replays saved responses instead, for testing and offline use.
+type pageSource interface {
+	fetch(ctx context.Context, apiURL, subject string, opts options) (page, error)
+}
+
+// apiSource fetches pages from the GitHub API.
+type apiSource struct{}
+
+func (apiSource) fetch(ctx context.Context, apiURL, subject string, opts options) (page, error) {
+	return fetchPageRetrying(ctx, apiURL, subject, opts)
+}
+
+// fileSource replays saved responses for --input. A file answers every
+// request; a directory of page1.json, page2.json, ... answers successive
+// requests for a feed with successive files, so multi-page feeds and
+// repeated fetches can be reproduced exactly. Each feed has its own
+// sequence, taken from a subdirectory named after the user (or
+// organization) if there is one, so several users replay the same way
+// however their fetches interleave.
+type fileSource struct {
+	path string
+	dir  bool
+
+	mu   sync.Mutex
+	next map[string]int // number of the file each feed's next request gets
+}
+
+// newFileSource checks that path exists and returns a source reading it.
+func newFileSource(path string) (*fileSource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSource{path: path, dir: info.IsDir(), next: make(map[string]int)}, nil
+}
+
+// fetch answers apiURL, the URL of a feed's first page. The page's next
+// link is apiURL again, which draws the following file of the same
+// sequence.
+func (s *fileSource) fetch(ctx context.Context, apiURL, subject string, opts options) (page, error) {
+	if !s.dir {
+		body, err := os.ReadFile(s.path)
+		if err != nil {
+			return page{}, fmt.Errorf("Failed to read --input. Reason: %v", err)
+		}
+		return page{body: body}, nil
+	}
+
+	s.mu.Lock()
+	s.next[apiURL]++
+	n := s.next[apiURL]
+	s.mu.Unlock()
+
+	dir := s.feedDir(apiURL)
+	name := pageFile(dir, n)
+	body, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return page{}, fmt.Errorf("Ran out of --input files: there is no %s.", name)
+	}
+	if err != nil {
+		return page{}, fmt.Errorf("Failed to read --input. Reason: %v", err)
+	}
+	pg := page{body: body}
+	// There is a next page as long as there is a next file.
+	if _, err := os.Stat(pageFile(dir, n+1)); err == nil {
+		pg.next = apiURL
+	}
+	return pg, nil
+}
+
+// feedDir is the directory holding the pages of the feed at apiURL: the
+// subdirectory named after its user or organization if there is one, or
+// else the --input directory itself.
+func (s *fileSource) feedDir(apiURL string) string {
+	owner := userInPath(apiURL)
+	if owner == "" {
+		_, rest, _ := strings.Cut(apiURL, "/orgs/")
+		owner, _, _ = strings.Cut(rest, "/")
+	}
+	if owner == "" {
+		return s.path
+	}
+	dir := filepath.Join(s.path, owner)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	return s.path
+}
+
+// pageFile is the path of the nth file in an --input directory.
+func pageFile(dir string, n int) string {
+	return filepath.Join(dir, fmt.Sprintf("page%d.json", n))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// spinnerFrames are drawn in order, one per tick.
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// spinner draws a small progress animation on a terminal while pages are
+// being fetched. A nil *spinner is valid and does nothing, which keeps the
+// call sites free of "is it enabled?" checks.
+type spinner struct {
+	w        io.Writer
+	mu       sync.Mutex
+	msg      string
+	stop     chan struct{}
+	done     chan struct{}
+	started  bool
+	stopOnce sync.Once
+}
+
+// newSpinner returns a spinner writing to w, or nil when disabled.
+func newSpinner(w io.Writer, enabled bool) *spinner {
+	if !enabled {
+		return nil
+	}
+	return &spinner{
+		w:    w,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start begins animating in the background.
+func (s *spinner) Start() {
+	if s == nil {
+		return
+	}
+	s.started = true
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			s.mu.Lock()
+			fmt.Fprintf(s.w, "\r%s %s", spinnerFrames[i%len(spinnerFrames)], s.msg)
+			s.mu.Unlock()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SetPage updates the message to show which page is being fetched.
+func (s *spinner) SetPage(page int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.msg = fmt.Sprintf("Fetching page %d...", page)
+	s.mu.Unlock()
+}
+
+// Stop halts the animation and clears the spinner line so that whatever
+// is printed next starts on a clean line. It is safe to call more than once.
+func (s *spinner) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if !s.started {
+			return
+		}
+		close(s.stop)
+		<-s.done
+		fmt.Fprint(s.w, "\r\033[K")
+	})
+}
+
+// isTerminal reports whether f is a terminal. Being a character device
+// isn't enough: /dev/null is one too.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+// standupTask is one checklist item of the standup output: an issue or
+// pull request in the state its most recent event left it in.
+type standupTask struct {
+	kind  string // "issue" or "pull request"
+	title string
+	url   string
+	verb  string // what happened last, e.g. "Opened" or "Merged"
+	done  bool
+}
+
+// writeStandup renders the issues and pull requests in events as a
+// GitHub-flavored task list under a header per repository: opened and
+// reopened ones as open tasks, closed and merged ones as checked. An issue
+// or pull request with several events is listed once, in the state of its
+// most recent event. Other event types are left out.
+func writeStandup(w io.Writer, heading string, events []Event, opts options) error {
+	fmt.Fprintf(w, "## Standup for %s\n", markdownEscaper.Replace(heading))
+	listed := false
+	for _, g := range groupEvents(events, "repo", location(opts)) {
+		seen := make(map[string]bool)
+		var tasks []standupTask
+		for _, event := range g.Events {
+			task, ok := newStandupTask(event)
+			if !ok {
+				continue
+			}
+			key := task.url
+			if key == "" {
+				key = task.kind + "\x00" + task.title
+			}
+			// Events come newest first, so the first one seen is the
+			// latest state.
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			tasks = append(tasks, task)
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		listed = true
+
+		fmt.Fprintf(w, "\n### %s\n\n", markdownEscaper.Replace(displayRepo(g.Events[0].Repo, opts)))
+		for _, task := range tasks {
+			box := " "
+			if task.done {
+				box = "x"
+			}
+			line := task.verb + " " + task.kind
+			if task.title != "" {
+				line += ": " + markdownText(task.title, opts)
+			}
+			if task.url != "" {
+				line += fmt.Sprintf(" ([link](%s))", task.url)
+			}
+			if _, err := fmt.Fprintf(w, "- [%s] %s\n", box, line); err != nil {
+				return err
+			}
+		}
+	}
+	if !listed {
+		_, err := fmt.Fprintln(w, "\nNothing opened, closed or merged.")
+		return err
+	}
+	return nil
+}
+
+// newStandupTask turns an IssuesEvent or PullRequestEvent into a task. It
+// reports false for other events and for actions, such as labeling, that
+// neither open nor close anything.
+func newStandupTask(event Event) (standupTask, bool) {
+	var task standupTask
+	var issue Issue
+	switch event.Type {
+	case "IssuesEvent":
+		task.kind, issue = "issue", event.Payload.Issue
+	case "PullRequestEvent":
+		task.kind, issue = "pull request", event.Payload.PullRequest
+	default:
+		return task, false
+	}
+	task.title, task.url = issue.Title, issue.HTMLURL
+
+	switch event.Payload.Action {
+	case "opened", "reopened":
+		task.verb = activity.ActionVerb(event.Payload.Action, "")
+	case "closed":
+		task.verb, task.done = "Closed", true
+		if issue.Merged {
+			task.verb = "Merged"
+		}
+	default:
+		return task, false
+	}
+	return task, true
+}
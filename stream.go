@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// textStream prints a feed in --format text page by page, as each page
+// arrives, instead of once the whole feed is in. Only the outputs that can
+// be written event by event can stream; validateFlags rejects --stream
+// with the ones that need every event first, such as --group-by.
+type textStream struct {
+	ctx  context.Context
+	w    io.Writer
+	opts options
+
+	started bool // the heading has been written
+	shown   int  // events printed so far
+}
+
+// page filters and prints one page's events, starting with the heading.
+func (s *textStream) page(f feed, events []Event) error {
+	if !s.started {
+		fmt.Fprintf(s.w, "Recent Activity for %s:\n\n", f.heading)
+		s.started = true
+	}
+	opts := s.opts
+	opts.queried = strings.Split(f.login, ",")
+	events = filterEvents(events, opts)
+	notePrivate(events, opts)
+	if opts.onlyNew {
+		var err error
+		if events, err = onlyNew(events); err != nil {
+			return err
+		}
+	}
+	if opts.enrichCommits {
+		enrichCommits(s.ctx, events, opts)
+	}
+	if opts.resolveState {
+		resolveState(s.ctx, events, opts)
+	}
+	printEvents(s.w, events, f.showActor, opts)
+	s.shown += len(events)
+	return nil
+}
+
+// finish writes what can only be known once the feed is complete: the
+// warning about unparsed events and the message for an empty result.
+func (s *textStream) finish(f feed) error {
+	if len(f.parseErrors) > 0 && !s.opts.quiet {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %d event(s) for %s that couldn't be parsed (see parse_errors in --format json).\n", len(f.parseErrors), f.heading)
+	}
+	if !s.started {
+		fmt.Fprintf(s.w, "Recent Activity for %s:\n\n", f.heading)
+	}
+	if s.shown == 0 && !s.opts.quiet {
+		if len(f.events) == 0 {
+			fmt.Fprintln(s.w, "No recent public activity found.")
+		} else {
+			fmt.Fprintf(s.w, "No events matched the given filters (%d fetched).\n", len(f.events))
+		}
+	}
+	return nil
+}
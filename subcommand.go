@@ -0,0 +1,191 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// subcommand is one of the verbs that can start the command line, such as
+// "summary" in `github-activity summary octocat`. Each may add flags of
+// its own on top of the common ones.
+type subcommand struct {
+	about string              // one line for the usage message
+	flags func(opts *options) // registers the subcommand's own flags
+}
+
+// subcommandNames lists the subcommands in the order usage shows them.
+var subcommandNames = []string{"fetch", "summary", "watch"}
+
+// subcommands maps every subcommand to its definition. A command line that
+// doesn't start with one of them is a fetch, so `github-activity octocat`
+// keeps working; `github-activity fetch watch` queries a user named watch.
+var subcommands = map[string]subcommand{
+	"fetch": {
+		about: "print the users' recent activity (the default)",
+		flags: func(opts *options) {},
+	},
+	"summary": {
+		about: "print how many events there are per type, repo, action or day",
+		flags: func(opts *options) {
+			flag.StringVar(&opts.countBy, "by", "type", "what to count events by: "+strings.Join(countDimensions, ", "))
+		},
+	},
+	"watch": {
+		about: "keep printing new events as they happen, until interrupted",
+		flags: func(opts *options) {
+			// The same as fetch --watch; --interval is a common flag.
+			opts.watch = true
+		},
+	},
+}
+
+// splitSubcommand takes the subcommand off the front of args, defaulting
+// to fetch.
+func splitSubcommand(args []string) (string, []string) {
+	if len(args) > 0 {
+		if _, ok := subcommands[args[0]]; ok {
+			return args[0], args[1:]
+		}
+	}
+	return "fetch", args
+}
+
+// checkSubcommandPlace rejects a command given after the flags, as in
+// `github-activity --token x summary octocat`, which would otherwise query
+// a user named summary. explicit reports whether a command came first;
+// after one the name is a username, as in `github-activity fetch summary`.
+func checkSubcommandPlace(explicit bool, usernames []string) error {
+	if explicit || len(usernames) == 0 {
+		return nil
+	}
+	if _, ok := subcommands[usernames[0]]; !ok {
+		return nil
+	}
+	name := usernames[0]
+	return fmt.Errorf("The %s command must come before any flags, e.g. github-activity %s [flags] <username>. To query a user named %s, use github-activity fetch [flags] %s.", name, name, name, name)
+}
+
+// defaultWatchInterval is how often watch asks for new events. The events
+// API itself only refreshes about once a minute.
+const defaultWatchInterval = time.Minute
+
+// runWatch prints the users' activity, then checks each --interval for
+// events it hasn't printed yet and prints just those, until the context
+// ends or the process is interrupted. Every round goes through feedEvents,
+// so the filters and lookups apply to the new events as they did to the
+// first ones. Errors on later checks are printed and the watch goes on;
+// GitHub's hiccups shouldn't end it. The exit code is that of the first
+// round, as in a normal run.
+func runWatch(ctx context.Context, w io.Writer, usernames []string, opts options) int {
+	seen := make(map[string]bool)
+	seed := opts.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// The first round is a normal run's output. A user whose first fetch
+	// fails is reported and left out of the rounds after it, like a failed
+	// user in a normal run, while the others keep being watched.
+	codes := make([]int, len(usernames))
+	var watched []string
+	for i, username := range usernames {
+		f, err := fetchFeed(ctx, username, opts, newProgress(nil))
+		if err == nil {
+			for _, event := range f.events {
+				seen[event.ID] = true
+			}
+			if len(watched) > 0 {
+				fmt.Fprintln(w)
+			}
+			endGroup := watchGroup(w, username, opts)
+			err = showFeed(ctx, w, f, opts)
+			endGroup()
+		}
+		if err != nil {
+			printError(w, err, opts)
+			codes[i] = exitCode(err)
+			continue
+		}
+		watched = append(watched, username)
+	}
+	code := reportFailures(usernames, codes, opts)
+	if len(watched) == 0 {
+		return code
+	}
+	saveETags()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return code
+		case <-time.After(watchWait(opts, rng)):
+		}
+
+		for _, username := range watched {
+			f, err := fetchFeed(ctx, username, opts, newProgress(nil))
+			if err != nil {
+				printError(w, err, opts)
+				continue
+			}
+			var fresh []Event
+			for _, event := range f.events {
+				if !seen[event.ID] {
+					fresh = append(fresh, event)
+				}
+				seen[event.ID] = true
+			}
+			f.events = fresh
+			events, err := feedEvents(ctx, f, opts)
+			if err != nil {
+				printError(w, err, opts)
+				continue
+			}
+			if len(events) == 0 {
+				continue
+			}
+			endGroup := watchGroup(w, username, opts)
+			printEvents(w, events, f.showActor || len(usernames) > 1, opts)
+			endGroup()
+		}
+		// Watching runs until interrupted, so each round saves the cache.
+		saveETags()
+	}
+}
+
+// watchGroup opens a collapsible group for username's lines in an Actions
+// log, as getGithubActivity does in a normal run, and returns what closes
+// it. Without --github-actions both do nothing.
+func watchGroup(w io.Writer, username string, opts options) (end func()) {
+	if !opts.githubActions {
+		return func() {}
+	}
+	fmt.Fprintf(w, "::group::GitHub activity for %s\n", escapeWorkflowData(cmp.Or(username, opts.org)))
+	return func() { fmt.Fprintln(w, "::endgroup::") }
+}
+
+// watchWait is how long watch waits before the next check: --interval,
+// moved by up to --interval-jitter either way so that many copies started
+// together don't keep polling in step.
+func watchWait(opts options, rng *rand.Rand) time.Duration {
+	if opts.intervalJitter <= 0 {
+		return opts.interval
+	}
+	offset := time.Duration(rng.Int63n(int64(2*opts.intervalJitter)+1)) - opts.intervalJitter
+	return max(opts.interval+offset, time.Second)
+}
+
+// subcommandUsage writes the list of subcommands for the usage message.
+func subcommandUsage(w io.Writer) {
+	fmt.Fprintf(w, "Commands:\n")
+	for _, name := range subcommandNames {
+		fmt.Fprintf(w, "  %-8s %s\n", name, subcommands[name].about)
+	}
+	fmt.Fprintln(w)
+}
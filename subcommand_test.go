@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchEnrichesLaterRounds(t *testing.T) {
+	push := func(id, sha string) string {
+		return fmt.Sprintf(`{"id":"%s","type":"PushEvent","repo":{"name":"o/r"},"payload":{"ref":"refs/heads/main","size":1,"commits":[{"sha":"%s"}]},"created_at":"2024-05-01T10:00:00Z"}`, id, sha)
+	}
+	// The timeout only ends a watch that never gets to the second commit.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var (
+		mu    sync.Mutex
+		polls int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/users/alice/events":
+			polls++
+			if polls == 1 {
+				fmt.Fprintf(w, "[%s]", push("1", "aaaaaaa111"))
+				return
+			}
+			fmt.Fprintf(w, "[%s,%s]", push("2", "bbbbbbb222"), push("1", "aaaaaaa111"))
+		case "/repos/o/r/commits/aaaaaaa111":
+			w.Write([]byte(`{"stats":{"additions":1,"deletions":2}}`))
+		case "/repos/o/r/commits/bbbbbbb222":
+			w.Write([]byte(`{"stats":{"additions":30,"deletions":4}}`))
+			// The second poll is all this test needs; end the watch
+			// during the wait before the third.
+			time.AfterFunc(50*time.Millisecond, cancel)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	opts := options{
+		baseURL:       srv.URL,
+		source:        apiSource{},
+		format:        "text",
+		watch:         true,
+		interval:      300 * time.Millisecond,
+		enrichCommits: true,
+		githubActions: true,
+		concurrency:   1,
+		maxBodySize:   1 << 20,
+		sampleRate:    1,
+		noCache:       true,
+		absolute:      true,
+		timezone:      time.UTC,
+	}
+	var out strings.Builder
+	if code := runWatch(ctx, &out, []string{"alice"}, opts); code != exitOK {
+		t.Fatalf("exit code = %d", code)
+	}
+	want := "::group::GitHub activity for alice\n" +
+		"Recent Activity for alice:\n\n" +
+		"- Pushed 1 commit(s) to o/r (2024-05-01T10:00:00Z)\n" +
+		"  aaaaaaa +1/-2\n" +
+		"::endgroup::\n" +
+		"::group::GitHub activity for alice\n" +
+		"- Pushed 1 commit(s) to o/r (2024-05-01T10:00:00Z)\n" +
+		"  bbbbbbb +30/-4\n" +
+		"::endgroup::\n"
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
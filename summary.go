@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+// countDimensions are the values accepted by --count-by.
+var countDimensions = []string{"type", "repo", "action", "day"}
+
+// kv is one row of a frequency table.
+type kv struct {
+	Key   string
+	Count int
+}
+
+// countBy tallies events by the given dimension, most frequent first and
+// alphabetically among equal counts, so the order doesn't depend on the
+// order of the events. Only keys that occur are listed, never with a count
+// of zero. Days are taken in loc.
+func countBy(events []Event, dimension string, loc *time.Location) []kv {
+	counts := make(map[string]int)
+	for _, event := range events {
+		counts[dimensionKey(event, dimension, loc)]++
+	}
+	return sortCounts(counts)
+}
+
+// sortCounts turns counts into rows in countBy's order.
+func sortCounts(counts map[string]int) []kv {
+	rows := make([]kv, 0, len(counts))
+	for key, count := range counts {
+		rows = append(rows, kv{Key: key, Count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	return rows
+}
+
+// dimensionKey is the value of event along dimension.
+func dimensionKey(event Event, dimension string, loc *time.Location) string {
+	switch dimension {
+	case "repo":
+		return activity.RepoName(event.Repo)
+	case "action":
+		if event.Payload.Action == "" {
+			return "(none)"
+		}
+		return event.Payload.Action
+	case "day":
+		return event.CreatedAt.In(loc).Format("2006-01-02")
+	}
+	return event.Type
+}
+
+// group is a run of events sharing a --group-by key.
+type group struct {
+	Key    string
+	Events []Event
+}
+
+// groupEvents splits events by dimension, keeping groups in the order
+// their first event appears. Events come newest first, so days come out
+// newest first and repositories by most recent activity.
+func groupEvents(events []Event, dimension string, loc *time.Location) []group {
+	var groups []group
+	index := make(map[string]int)
+	for _, event := range events {
+		key := dimensionKey(event, dimension, loc)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, group{Key: key})
+		}
+		groups[i].Events = append(groups[i].Events, event)
+	}
+	return groups
+}
+
+// printTable writes rows as two aligned columns under a header.
+func printTable(w io.Writer, dimension string, rows []kv, opts options) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCOUNT\n", strings.ToUpper(dimension))
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\n", row.Key, formatCount(row.Count, opts))
+	}
+	return tw.Flush()
+}
+
+// touchedRepos returns the names of the repositories events are on, each
+// once and sorted. Names differing only in case are the same repository
+// to GitHub, so only the first spelling seen is kept. Events on deleted
+// repositories have no name and are left out.
+func touchedRepos(events []Event) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	for _, event := range events {
+		key := normalizeRepo(event.Repo.Name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		repos = append(repos, event.Repo.Name)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return normalizeRepo(repos[i]) < normalizeRepo(repos[j])
+	})
+	return repos
+}
+
+// renderBarChart draws counts as a horizontal bar chart width columns
+// wide, in countBy's order, with the longest bar for the largest count:
+//
+//	PushEvent    ################## 12
+//	IssuesEvent  ###### 4
+//
+// Every non-zero count gets at least one "#", however small.
+func renderBarChart(counts map[string]int, width int) string {
+	rows := sortCounts(counts)
+	if len(rows) == 0 {
+		return ""
+	}
+	labelWidth, countWidth := 0, 0
+	for _, row := range rows {
+		labelWidth = max(labelWidth, len(row.Key))
+		countWidth = max(countWidth, len(strconv.Itoa(row.Count)))
+	}
+	barWidth := max(width-labelWidth-countWidth-3, 1)
+	largest := rows[0].Count
+
+	var b strings.Builder
+	for _, row := range rows {
+		bar := 0
+		if largest > 0 {
+			bar = max(row.Count*barWidth/largest, min(row.Count, 1))
+		}
+		fmt.Fprintf(&b, "%-*s  %s %d\n", labelWidth, row.Key, strings.Repeat("#", bar), row.Count)
+	}
+	return b.String()
+}
+
+// printReposSummary writes one row per repository with its event count and
+// most recent activity, most recently active first.
+func printReposSummary(w io.Writer, events []Event, opts options) error {
+	loc := location(opts)
+	groups := groupEvents(events, "repo", loc)
+	last := make(map[string]time.Time, len(groups))
+	for _, g := range groups {
+		for _, event := range g.Events {
+			if event.CreatedAt.After(last[g.Key]) {
+				last[g.Key] = event.CreatedAt
+			}
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return last[groups[i].Key].After(last[groups[j].Key])
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tEVENTS\tLAST ACTIVITY")
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", g.Key, formatCount(len(g.Events), opts), last[g.Key].In(loc).Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
+
+// formatCount renders a count for the summaries: as a plain integer, or
+// abbreviated with --humanize-counts.
+func formatCount(n int, opts options) string {
+	if opts.humanizeCounts {
+		return humanizeCount(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// humanizeCount abbreviates n with a k or M suffix, keeping one decimal
+// below ten: 999, 1.2k, 12k, 3.4M. A count that would round up to 1000 of
+// a unit takes the next one, so 999999 is 1M rather than 1000k.
+func humanizeCount(n int) string {
+	units := []struct {
+		size   float64
+		suffix string
+	}{{1e6, "M"}, {1e3, "k"}}
+	for _, u := range units {
+		if v := float64(n) / u.size; math.Abs(v) >= 0.9995 {
+			if v < 10 && v > -10 {
+				return strings.TrimSuffix(strconv.FormatFloat(v, 'f', 1, 64), ".0") + u.suffix
+			}
+			return strconv.FormatFloat(v, 'f', 0, 64) + u.suffix
+		}
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ichsand/pkg/activity"
+)
+
+func TestHumanizeCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{7, "7"},
+		{999, "999"},
+		{1000, "1k"},
+		{1200, "1.2k"},
+		{9940, "9.9k"},
+		{15300, "15k"},
+		{999999, "1M"},
+		{1000000, "1M"},
+		{2500000, "2.5M"},
+		{-1200, "-1.2k"},
+	}
+	for _, tt := range tests {
+		if got := humanizeCount(tt.n); got != tt.want {
+			t.Errorf("humanizeCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCountByListsOnlyOccurringKeys(t *testing.T) {
+	var events []Event
+	for _, eventType := range []string{"WatchEvent", "PushEvent", "IssuesEvent", "PushEvent", "IssuesEvent"} {
+		events = append(events, Event{Event: activity.Event{Type: eventType}})
+	}
+	rows := countBy(events, "type", time.UTC)
+	want := []kv{{"IssuesEvent", 2}, {"PushEvent", 2}, {"WatchEvent", 1}}
+	if !slices.Equal(rows, want) {
+		t.Errorf("countBy = %v, want %v (ties alphabetically)", rows, want)
+	}
+
+	var b strings.Builder
+	if err := printTable(&b, "type", rows, options{}); err != nil {
+		t.Fatal(err)
+	}
+	// Supported types that didn't occur must not be padded in as zeros.
+	for _, absent := range []string{"ForkEvent", "CreateEvent", " 0\n"} {
+		if strings.Contains(b.String(), absent) {
+			t.Errorf("table contains %q:\n%s", absent, b.String())
+		}
+	}
+	if want := "TYPE         COUNT\nIssuesEvent  2\nPushEvent    2\nWatchEvent   1\n"; b.String() != want {
+		t.Errorf("table:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	if rows := countBy(nil, "type", time.UTC); len(rows) != 0 {
+		t.Errorf("countBy of no events = %v, want no rows", rows)
+	}
+}
+
+func TestTouchedReposDedupesAndSorts(t *testing.T) {
+	var events []Event
+	for _, name := range []string{"zed/tool", "Octocat/Hello-World", "", "alice/notes", "octocat/hello-world", "zed/tool", "Alice/Blog"} {
+		events = append(events, Event{Event: activity.Event{Repo: activity.Repo{Name: name}}})
+	}
+	want := []string{"Alice/Blog", "alice/notes", "Octocat/Hello-World", "zed/tool"}
+	if got := touchedRepos(events); !slices.Equal(got, want) {
+		t.Errorf("touchedRepos = %v, want %v", got, want)
+	}
+	if got := touchedRepos(nil); len(got) != 0 {
+		t.Errorf("touchedRepos(nil) = %v, want none", got)
+	}
+}
+
+func TestRenderBarChart(t *testing.T) {
+	got := renderBarChart(map[string]int{"PushEvent": 12, "IssuesEvent": 4, "WatchEvent": 1}, 40)
+	want := "PushEvent    ######################## 12\n" +
+		"IssuesEvent  ######## 4\n" +
+		"WatchEvent   ## 1\n"
+	if got != want {
+		t.Errorf("chart at width 40:\n%s\nwant:\n%s", got, want)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+		if len(line) > 40 {
+			t.Errorf("%q is wider than 40 columns", line)
+		}
+	}
+
+	// A count too small for a whole column still gets one, and a width
+	// too narrow for the labels still draws bars.
+	if got, want := renderBarChart(map[string]int{"a": 1000, "b": 1}, 20), "a  ############ 1000\nb  # 1\n"; got != want {
+		t.Errorf("chart at width 20:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := renderBarChart(map[string]int{"PushEvent": 2}, 5), "PushEvent  # 2\n"; got != want {
+		t.Errorf("chart at width 5 = %q, want %q", got, want)
+	}
+	if got := renderBarChart(nil, 40); got != "" {
+		t.Errorf("chart of no counts = %q, want nothing", got)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// columnFields are the fields --columns can pick from, each rendering one
+// cell of a csv or table row.
+var columnFields = map[string]func(event Event, opts options) string{
+	"id":          func(event Event, opts options) string { return event.ID },
+	"time":        func(event Event, opts options) string { return event.CreatedAt.In(location(opts)).Format(time.RFC3339) },
+	"type":        func(event Event, opts options) string { return event.Type },
+	"actor":       func(event Event, opts options) string { return event.Actor.Login },
+	"repo":        func(event Event, opts options) string { return displayRepo(event.Repo, opts) },
+	"action":      func(event Event, opts options) string { return event.Payload.Action },
+	"description": func(event Event, opts options) string { return formatEvent(event, opts) },
+	"url":         func(event Event, opts options) string { return eventURL(event, opts) },
+}
+
+// columnNames lists columnFields in a stable order for help and errors.
+var columnNames = []string{"id", "time", "type", "actor", "repo", "action", "description", "url"}
+
+// defaultColumns is the column set used when --columns isn't given.
+const defaultColumns = "time,type,repo,description"
+
+// parseColumns validates a comma-separated --columns value.
+func parseColumns(value string) ([]string, error) {
+	columns := columnList(value)
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("--columns needs at least one column. Use %s.", strings.Join(columnNames, ", "))
+	}
+	for _, column := range columns {
+		if _, ok := columnFields[column]; !ok {
+			return nil, fmt.Errorf("Unknown column '%s'. Use %s.", column, strings.Join(columnNames, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// columnList splits a comma-separated list of columns, keeping its order.
+func columnList(value string) []string {
+	var columns []string
+	for _, column := range strings.Split(value, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// withActorColumn adds the actor column to columns if it isn't there,
+// after the time if that is one of them and first otherwise.
+func withActorColumn(columns []string) []string {
+	if slices.Contains(columns, "actor") {
+		return columns
+	}
+	at := 0
+	if i := slices.Index(columns, "time"); i >= 0 {
+		at = i + 1
+	}
+	return slices.Insert(slices.Clone(columns), at, "actor")
+}
+
+// eventRow renders the chosen columns of an event.
+func eventRow(event Event, columns []string, opts options) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = columnFields[column](event, opts)
+	}
+	return row
+}
+
+// writeCSV writes a header row followed by one row per event.
+func writeCSV(w io.Writer, events []Event, opts options) error {
+	cw := csv.NewWriter(w)
+	if opts.csvHeader == nil {
+		if err := cw.Write(opts.columns); err != nil {
+			return err
+		}
+	}
+	for _, event := range events {
+		if err := cw.Write(eventRow(event, opts.columns, opts)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeCSVSections writes several users' feeds as one CSV: a single
+// header and their rows in one timeline, newest first.
+func writeCSVSections(w io.Writer, sections []section, opts options) error {
+	_, events, _ := combineSections(sections)
+	if opts.flatten {
+		return writeFlatCSV(w, events, opts.columns, opts.csvHeader)
+	}
+	return writeCSV(w, events, opts)
+}
+
+// checkCSVHeader makes sure rows with columns can be appended to a CSV
+// file whose header is existing: with other columns they would sit under
+// the wrong headings. Flattened rows without --columns take the file's
+// columns, so only columns that were chosen can clash.
+func checkCSVHeader(columns, existing []string) error {
+	if existing == nil || len(columns) == 0 || slices.Equal(columns, existing) {
+		return nil
+	}
+	return fmt.Errorf("The --output file has the columns %s, not %s. Append to a file with the same columns, or choose them with --columns.", strings.Join(existing, ","), strings.Join(columns, ","))
+}
+
+// readCSVHeader returns the first row of the CSV file r, or nil if the
+// file is empty.
+func readCSVHeader(r io.Reader) ([]string, error) {
+	header, err := csv.NewReader(r).Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	return header, err
+}
+
+// writeEventTable writes the events as aligned columns under an upper-case
+// header, in the same style as the --count-by table.
+func writeEventTable(w io.Writer, events []Event, opts options) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(opts.columns, "\t")))
+	for _, event := range events {
+		// A tab or newline inside a cell would break the alignment.
+		row := eventRow(event, opts.columns, opts)
+		for i, cell := range row {
+			row[i] = strings.Join(strings.Fields(cell), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCSVForSeveralUsersIsOneFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		login := userInPath(r.URL.Path)
+		hour := map[string]int{"alice": 9, "bob": 11}[login]
+		fmt.Fprintf(w, `[
+			{"id":"%[1]s2","type":"WatchEvent","actor":{"login":"%[1]s"},"repo":{"name":"o/r"},"payload":{"action":"started"},"created_at":"2024-05-01T%02[2]d:00:00Z"},
+			{"id":"%[1]s1","type":"WatchEvent","actor":{"login":"%[1]s"},"repo":{"name":"o/r"},"payload":{"action":"started"},"created_at":"2024-05-01T%02[3]d:00:00Z"}
+		]`, login, hour, hour-2)
+	}))
+	defer srv.Close()
+
+	users := []string{"alice", "bob"}
+	opts := options{
+		baseURL:     srv.URL,
+		format:      "csv",
+		output:      filepath.Join(t.TempDir(), "out.csv"),
+		concurrency: 2,
+		maxBodySize: 1 << 20,
+		sampleRate:  1,
+		noCache:     true,
+	}
+	// validateFlags adds the actor column for several users.
+	raw := rawFlags{columns: defaultColumns, theme: "mono", utc: true}
+	if err := validateFlags(&opts, raw, users); err != nil {
+		t.Fatal(err)
+	}
+	if code := runWithDeadline(users, opts); code != exitOK {
+		t.Fatalf("exit code = %d", code)
+	}
+	written, err := os.ReadFile(opts.output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(written))).ReadAll()
+	if err != nil {
+		t.Fatalf("output isn't one CSV: %v\n%s", err, written)
+	}
+	if want := []string{"time", "actor", "type", "repo", "description"}; !slices.Equal(rows[0], want) {
+		t.Errorf("header %v, want %v", rows[0], want)
+	}
+	var order []string
+	for _, row := range rows[1:] {
+		order = append(order, row[0]+" "+row[1])
+	}
+	want := []string{
+		"2024-05-01T11:00:00Z bob",
+		"2024-05-01T09:00:00Z alice",
+		"2024-05-01T09:00:00Z bob",
+		"2024-05-01T07:00:00Z alice",
+	}
+	if !slices.Equal(order, want) {
+		t.Errorf("rows %q, want %q", order, want)
+	}
+}
+
+func TestWithActorColumn(t *testing.T) {
+	tests := []struct {
+		columns, want string
+	}{
+		{"time,type,repo", "time,actor,type,repo"},
+		{"repo,description", "actor,repo,description"},
+		{"repo,actor", "repo,actor"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(withActorColumn(columnList(tt.columns)), ","); got != tt.want {
+			t.Errorf("withActorColumn(%s) = %s, want %s", tt.columns, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+// themeNames lists the --theme values in the order help and errors show
+// them.
+var themeNames = []string{"dark", "light", "mono"}
+
+// themes maps every --theme value to the ANSI color (an SGR parameter) a
+// text line gets per event type. Types that aren't listed stay uncolored,
+// and so does everything with mono. The light theme avoids yellow and
+// cyan, which are hard to read on a white background.
+var themes = map[string]map[string]string{
+	"dark": {
+		"PushEvent":                     "32", // green
+		"PullRequestEvent":              "35", // magenta
+		"PullRequestReviewEvent":        "35",
+		"PullRequestReviewCommentEvent": "35",
+		"IssuesEvent":                   "33", // yellow
+		"IssueCommentEvent":             "36", // cyan
+		"CreateEvent":                   "94", // bright blue
+		"DeleteEvent":                   "31", // red
+		"ReleaseEvent":                  "92", // bright green
+		"WatchEvent":                    "90", // grey
+		"ForkEvent":                     "90",
+	},
+	"light": {
+		"PushEvent":                     "32",
+		"PullRequestEvent":              "35",
+		"PullRequestReviewEvent":        "35",
+		"PullRequestReviewCommentEvent": "35",
+		"IssuesEvent":                   "31",
+		"IssueCommentEvent":             "34", // blue
+		"CreateEvent":                   "34",
+		"DeleteEvent":                   "31",
+		"ReleaseEvent":                  "32",
+		"WatchEvent":                    "30", // black
+		"ForkEvent":                     "30",
+	},
+	"mono": {},
+}
+
+// colorize wraps line in the theme's color for eventType, if it has one.
+func colorize(line, eventType string, theme map[string]string) string {
+	color, ok := theme[eventType]
+	if !ok {
+		return line
+	}
+	return "\033[" + color + "m" + line + "\033[0m"
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// tuiToggles maps the keys that show or hide an event type in --tui mode.
+var tuiToggles = map[byte]string{
+	'p': "PushEvent",
+	'u': "PullRequestEvent",
+	'i': "IssuesEvent",
+	'o': "IssueCommentEvent",
+	'c': "CreateEvent",
+	'd': "DeleteEvent",
+	'w': "WatchEvent",
+	'f': "ForkEvent",
+}
+
+// Keys that aren't plain bytes are decoded from their escape sequences
+// into these values, which no single-byte key can collide with.
+const (
+	keyUp = 256 + iota
+	keyDown
+	keyPageUp
+	keyPageDown
+)
+
+// tui is the state of an interactive --tui session.
+type tui struct {
+	usernames []string
+	opts      options
+
+	heading   string
+	events    []Event // after the command-line filters
+	showActor bool
+	hidden    map[string]bool // types hidden with the toggle keys
+	top       int             // index of the first visible line
+	status    string          // shown in the footer until the next key
+}
+
+// canRunTUI reports whether --tui can take over the terminal. Without a
+// terminal on both ends the caller prints the usual output instead.
+func canRunTUI() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+// runTUI shows the users' events in a scrollable full-screen list until the
+// user quits.
+func runTUI(ctx context.Context, usernames []string, opts options) int {
+	t := &tui{usernames: usernames, opts: opts, hidden: make(map[string]bool)}
+	if err := t.load(ctx); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return exitCode(err)
+	}
+
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Printf("Error: Failed to set up the terminal. Reason: %v\n", err)
+		return exitFailure
+	}
+	// Switch to the alternate screen so the shell's scrollback is left as
+	// it was, and hide the cursor while the list is shown.
+	fmt.Print("\033[?1049h\033[?25l")
+	defer func() {
+		fmt.Print("\033[?25h\033[?1049l")
+		term.Restore(fd, state)
+	}()
+
+	for {
+		t.draw()
+		key, err := readKey()
+		if err != nil {
+			return exitOK
+		}
+		t.status = ""
+		switch key {
+		case 'q', 3: // 3 is Ctrl-C, which raw mode delivers as a byte
+			return exitOK
+		case 'j', keyDown:
+			t.scroll(1)
+		case 'k', keyUp:
+			t.scroll(-1)
+		case ' ', keyPageDown:
+			t.scroll(t.pageSize())
+		case 'b', keyPageUp:
+			t.scroll(-t.pageSize())
+		case 'g':
+			t.top = 0
+		case 'G':
+			t.scroll(len(t.events))
+		case 'a':
+			t.hidden = make(map[string]bool)
+			t.top = 0
+		case 'r':
+			t.status = "Refreshing..."
+			t.draw()
+			if err := t.load(ctx); err != nil {
+				t.status = err.Error()
+			} else {
+				t.status = "Refreshed."
+			}
+		default:
+			if key < 256 {
+				if eventType, ok := tuiToggles[byte(key)]; ok {
+					t.hidden[eventType] = !t.hidden[eventType]
+					t.top = 0
+				}
+			}
+		}
+	}
+}
+
+// load fetches every user's feed into a single timeline.
+func (t *tui) load(ctx context.Context) error {
+	prog := newProgress(nil)
+	var events []Event
+	var headings []string
+	showActor := t.opts.showActor || len(t.usernames) > 1
+	for _, username := range t.usernames {
+		f, err := fetchFeed(ctx, username, t.opts, prog)
+		if err != nil {
+			return err
+		}
+		opts := t.opts
+		opts.queried = []string{f.login}
+		events = append(events, filterEvents(f.events, opts)...)
+		headings = append(headings, f.heading)
+		showActor = showActor || f.showActor
+	}
+	sortTimeline(events)
+	t.heading = strings.Join(headings, ", ")
+	t.events = events
+	t.showActor = showActor
+	t.top = 0
+	return nil
+}
+
+// lines renders the events that aren't hidden.
+func (t *tui) lines() []string {
+	var lines []string
+	for _, event := range t.events {
+		if t.hidden[event.Type] {
+			continue
+		}
+		line := formatEvent(event, t.opts)
+		if t.showActor && event.Actor.Login != "" {
+			line = event.Actor.Login + " " + lowerFirst(line)
+		}
+		lines = append(lines, event.CreatedAt.In(location(t.opts)).Format("2006-01-02 15:04")+"  "+line)
+	}
+	return lines
+}
+
+// size returns the terminal's width and height.
+func (t *tui) size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return defaultWrapWidth, 24
+	}
+	return width, height
+}
+
+// pageSize is how many event lines fit between the header and the footer.
+func (t *tui) pageSize() int {
+	_, height := t.size()
+	return max(height-4, 1)
+}
+
+// scroll moves the view by n lines, keeping it within the list.
+func (t *tui) scroll(n int) {
+	t.top = min(t.top+n, len(t.lines())-t.pageSize())
+	t.top = max(t.top, 0)
+}
+
+// draw repaints the whole screen. Raw mode turns off the terminal's
+// newline translation, hence the explicit "\r\n".
+func (t *tui) draw() {
+	width, _ := t.size()
+	lines := t.lines()
+
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	header := fmt.Sprintf("Recent Activity for %s (%d events)", t.heading, len(lines))
+	if hidden := t.hiddenTypes(); len(hidden) > 0 {
+		header += " — hiding " + strings.Join(hidden, ", ")
+	}
+	b.WriteString("\033[1m" + truncate(header, width) + "\033[0m\r\n\r\n")
+
+	end := min(t.top+t.pageSize(), len(lines))
+	for _, line := range lines[t.top:end] {
+		b.WriteString(truncate(line, width) + "\r\n")
+	}
+	for i := end - t.top; i < t.pageSize(); i++ {
+		b.WriteString("\r\n")
+	}
+
+	footer := t.status
+	if footer == "" {
+		footer = "j/k scroll  space/b page  p/u/i/o/c/d/w/f toggle types  a show all  r refresh  q quit"
+	}
+	b.WriteString("\r\n\033[7m" + truncate(footer, width) + "\033[0m")
+	fmt.Print(b.String())
+}
+
+// hiddenTypes lists the toggled-off types in a stable order.
+func (t *tui) hiddenTypes() []string {
+	var types []string
+	for eventType, hidden := range t.hidden {
+		if hidden {
+			types = append(types, strings.TrimSuffix(eventType, "Event"))
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// truncate cuts s to at most width runes so that a long line doesn't wrap
+// and push the rest of the screen down.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:max(width-1, 0)]) + "…"
+}
+
+// readKey reads one key press from stdin, decoding the arrow and page keys.
+func readKey() (int, error) {
+	buf := make([]byte, 8)
+	n, err := os.Stdin.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	seq := string(buf[:n])
+	switch seq {
+	case "\033[A":
+		return keyUp, nil
+	case "\033[B":
+		return keyDown, nil
+	case "\033[5~":
+		return keyPageUp, nil
+	case "\033[6~":
+		return keyPageDown, nil
+	}
+	return int(buf[0]), nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// defaultWrapWidth is used when the terminal size can't be determined.
+const defaultWrapWidth = 80
+
+// terminalWidth returns the width of the terminal behind f, or
+// defaultWrapWidth if it can't be determined.
+func terminalWidth(f *os.File) int {
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil || width <= 0 {
+		return defaultWrapWidth
+	}
+	return width
+}
+
+// wrapLine breaks s at spaces so that no line is wider than width, indenting
+// continuation lines by indent spaces so they line up under the content
+// rather than under the "- " bullet. A word longer than a whole line is
+// left intact.
+func wrapLine(s string, width, indent int) string {
+	if len(s) <= width {
+		return s
+	}
+
+	var b strings.Builder
+	lineLen := 0
+	for i, word := range strings.Fields(s) {
+		switch {
+		case i == 0:
+		case lineLen+1+len(word) > width:
+			b.WriteString("\n")
+			b.WriteString(strings.Repeat(" ", indent))
+			lineLen = indent
+		default:
+			b.WriteString(" ")
+			lineLen++
+		}
+		b.WriteString(word)
+		lineLen += len(word)
+	}
+	return b.String()
+}